@@ -0,0 +1,138 @@
+// Package accesslog parses NGINX access log lines.
+package accesslog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// FormatCombined is the predefined combined format of NGINX.
+	FormatCombined = "combined"
+	// FormatJSON is a format writing every line as a JSON object whose keys
+	// are the names of the variables, as written with log_format escape=json.
+	FormatJSON = "json"
+
+	combinedLogFormat = `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent"`
+)
+
+// ErrNoMatch means a line does not match the log format.
+var ErrNoMatch = errors.New("line does not match the log format")
+
+var variableRE = regexp.MustCompile(`\$(?:\{([a-zA-Z0-9_]+)\}|([a-zA-Z0-9_]+))`)
+
+// Fields are the values of the variables of a log line by variable name,
+// without the leading $. NGINX writes "-" for empty values; they are empty
+// strings in Fields.
+type Fields map[string]string
+
+// Parser parses the lines of an access log.
+type Parser interface {
+	Parse(line string) (Fields, error)
+}
+
+// NewParser returns a parser for the format, which is FormatCombined,
+// FormatJSON or the definition of a log_format directive, such as
+// `$remote_addr [$time_local] "$request" $status`.
+func NewParser(format string) (Parser, error) {
+	switch format {
+	case "", FormatCombined:
+		return newFormatParser(combinedLogFormat)
+	case FormatJSON:
+		return jsonParser{}, nil
+	default:
+		return newFormatParser(format)
+	}
+}
+
+// formatParser parses the lines written by a log_format definition.
+type formatParser struct {
+	re    *regexp.Regexp
+	names []string
+}
+
+func newFormatParser(format string) (*formatParser, error) {
+	matches := variableRE.FindAllStringSubmatchIndex(format, -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("log format %q has no variables", format)
+	}
+
+	var pattern strings.Builder
+	names := make([]string, 0, len(matches))
+	pattern.WriteString("^")
+	last := 0
+	for i, m := range matches {
+		pattern.WriteString(regexp.QuoteMeta(format[last:m[0]]))
+		// Every variable but the last one ends at the first occurrence of
+		// the text following it.
+		if i == len(matches)-1 && m[1] == len(format) {
+			pattern.WriteString("(.*)")
+		} else {
+			pattern.WriteString("(.*?)")
+		}
+		if m[2] >= 0 {
+			names = append(names, format[m[2]:m[3]])
+		} else {
+			names = append(names, format[m[4]:m[5]])
+		}
+		last = m[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(format[last:]))
+	pattern.WriteString("$")
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid log format %q: %w", format, err)
+	}
+	return &formatParser{re: re, names: names}, nil
+}
+
+func (p *formatParser) Parse(line string) (Fields, error) {
+	values := p.re.FindStringSubmatch(line)
+	if values == nil {
+		return nil, ErrNoMatch
+	}
+
+	fields := make(Fields, len(p.names))
+	for i, name := range p.names {
+		value := values[i+1]
+		if value == "-" {
+			value = ""
+		}
+		if _, ok := fields[name]; !ok || value != "" {
+			fields[name] = value
+		}
+	}
+	return fields, nil
+}
+
+// jsonParser parses lines written as JSON objects.
+type jsonParser struct{}
+
+func (jsonParser) Parse(line string) (Fields, error) {
+	d := json.NewDecoder(strings.NewReader(line))
+	d.UseNumber()
+	var values map[string]any
+	if err := d.Decode(&values); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNoMatch, err)
+	}
+
+	fields := make(Fields, len(values))
+	for name, value := range values {
+		switch v := value.(type) {
+		case nil:
+			fields[name] = ""
+		case string:
+			if v == "-" {
+				v = ""
+			}
+			fields[name] = v
+		default:
+			fields[name] = fmt.Sprint(v)
+		}
+	}
+	return fields, nil
+}
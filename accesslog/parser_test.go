@@ -0,0 +1,115 @@
+package accesslog
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParser(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		want    Fields
+		wantErr error
+		name    string
+		format  string
+		line    string
+	}{
+		{
+			name:   "combined",
+			format: FormatCombined,
+			line:   `192.0.2.1 - - [17/Oct/2026:10:00:00 +0000] "GET /api/users?id=1 HTTP/1.1" 200 612 "-" "curl/8.0.1"`,
+			want: Fields{
+				"remote_addr":     "192.0.2.1",
+				"remote_user":     "",
+				"time_local":      "17/Oct/2026:10:00:00 +0000",
+				"request":         "GET /api/users?id=1 HTTP/1.1",
+				"status":          "200",
+				"body_bytes_sent": "612",
+				"http_referer":    "",
+				"http_user_agent": "curl/8.0.1",
+			},
+		},
+		{
+			name:   "default format",
+			format: "",
+			line:   `192.0.2.1 - alice [17/Oct/2026:10:00:00 +0000] "POST /login HTTP/2.0" 302 0 "https://example.com/" "Mozilla/5.0 (X11; Linux x86_64)"`,
+			want: Fields{
+				"remote_addr":     "192.0.2.1",
+				"remote_user":     "alice",
+				"time_local":      "17/Oct/2026:10:00:00 +0000",
+				"request":         "POST /login HTTP/2.0",
+				"status":          "302",
+				"body_bytes_sent": "0",
+				"http_referer":    "https://example.com/",
+				"http_user_agent": "Mozilla/5.0 (X11; Linux x86_64)",
+			},
+		},
+		{
+			name:   "custom format with braces and trailing text",
+			format: `${host}:$server_port $request_method $uri $status ${request_length}b ${bytes_sent}b;`,
+			line:   `example.com:443 GET /index.html 404 310b 521b;`,
+			want: Fields{
+				"host":           "example.com",
+				"server_port":    "443",
+				"request_method": "GET",
+				"uri":            "/index.html",
+				"status":         "404",
+				"request_length": "310",
+				"bytes_sent":     "521",
+			},
+		},
+		{
+			name:   "json",
+			format: FormatJSON,
+			line:   `{"host":"example.com","request":"GET / HTTP/1.1","status":200,"bytes_sent":"1024","http_referer":"-","upstream_addr":null}`,
+			want: Fields{
+				"host":          "example.com",
+				"request":       "GET / HTTP/1.1",
+				"status":        "200",
+				"bytes_sent":    "1024",
+				"http_referer":  "",
+				"upstream_addr": "",
+			},
+		},
+		{
+			name:    "line not matching the format",
+			format:  FormatCombined,
+			line:    `2026/10/17 10:00:00 [error] 12#12: *1 open() failed`,
+			wantErr: ErrNoMatch,
+		},
+		{
+			name:    "invalid json",
+			format:  FormatJSON,
+			line:    `{"host":`,
+			wantErr: ErrNoMatch,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p, err := NewParser(tt.format)
+			if err != nil {
+				t.Fatalf("NewParser() returned error: %v", err)
+			}
+			got, err := p.Parse(tt.line)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewParserWithoutVariables(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewParser("static text"); err == nil {
+		t.Errorf("NewParser() returned no error for a format without variables")
+	}
+}
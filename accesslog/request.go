@@ -0,0 +1,129 @@
+package accesslog
+
+import (
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// methods are the request methods reported as is. Others are reported as
+// "other", so malformed requests cannot create new series.
+var methods = map[string]bool{
+	"GET": true, "HEAD": true, "POST": true, "PUT": true, "DELETE": true,
+	"CONNECT": true, "OPTIONS": true, "TRACE": true, "PATCH": true,
+}
+
+// Request is the request described by the fields of a log line.
+type Request struct {
+	Method string
+	Host   string
+	Path   string
+	Status string
+	// BytesSent is $bytes_sent, or $body_bytes_sent when the line lacks it.
+	BytesSent int64
+	// BytesReceived is $request_length.
+	BytesReceived int64
+}
+
+// Request returns the request described by the fields. The method and path
+// are taken from $request_method and $request_uri or $uri, or else from
+// $request. The host is $host, $http_host or $server_name.
+func (f Fields) Request() Request {
+	r := Request{
+		Method: f["request_method"],
+		Host:   strings.ToLower(firstNonEmpty(f["host"], f["http_host"], f["server_name"])),
+		Path:   firstNonEmpty(f["request_uri"], f["uri"]),
+		Status: f["status"],
+	}
+
+	// $request is "METHOD URI PROTOCOL".
+	if request := strings.Fields(f["request"]); len(request) >= 2 {
+		if r.Method == "" {
+			r.Method = request[0]
+		}
+		if r.Path == "" {
+			r.Path = request[1]
+		}
+	}
+	if r.Method != "" && !methods[r.Method] {
+		r.Method = "other"
+	}
+	if path, _, ok := strings.Cut(r.Path, "?"); ok {
+		r.Path = path
+	}
+
+	r.BytesSent = parseInt(firstNonEmpty(f["bytes_sent"], f["body_bytes_sent"]))
+	r.BytesReceived = parseInt(f["request_length"])
+	return r
+}
+
+// Route names the requests whose path matches Regexp.
+type Route struct {
+	Regexp *regexp.Regexp
+	Name   string
+}
+
+// MatchRoute returns the name of the first route matching path, or "other"
+// when none does. Without routes, it returns an empty string.
+func MatchRoute(routes []Route, path string) string {
+	if len(routes) == 0 {
+		return ""
+	}
+	for _, route := range routes {
+		if route.Regexp.MatchString(path) {
+			return route.Name
+		}
+	}
+	return "other"
+}
+
+// MatchHost returns the first of hosts matching the host of a request, without
+// its port and the brackets of IPv6 addresses, or "other" when none does, so requests with arbitrary Host headers
+// cannot create new series. A host starting with "*." matches the subdomains
+// of the rest, like a wildcard server name of NGINX. Without hosts, it returns
+// an empty string.
+func MatchHost(hosts []string, host string) string {
+	if len(hosts) == 0 {
+		return ""
+	}
+	host = hostname(host)
+	for _, h := range hosts {
+		h = strings.ToLower(h)
+		if suffix, ok := strings.CutPrefix(h, "*"); ok && strings.HasPrefix(suffix, ".") {
+			if strings.HasSuffix(host, suffix) {
+				return h
+			}
+			continue
+		}
+		if host == hostname(h) {
+			return h
+		}
+	}
+	return "other"
+}
+
+// hostname returns host without its port and the brackets of an IPv6 address.
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func parseInt(s string) int64 {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,99 @@
+package accesslog
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestFieldsRequest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		fields Fields
+		name   string
+		want   Request
+	}{
+		{
+			name:   "request line",
+			fields: Fields{"request": "GET /api/users?id=1 HTTP/1.1", "status": "200", "body_bytes_sent": "612", "http_host": "Example.com:8080"},
+			want:   Request{Method: "GET", Host: "example.com:8080", Path: "/api/users", Status: "200", BytesSent: 612},
+		},
+		{
+			name:   "variables",
+			fields: Fields{"request_method": "PATCH", "request_uri": "/items/1", "host": "example.com", "status": "204", "bytes_sent": "180", "body_bytes_sent": "0", "request_length": "420"},
+			want:   Request{Method: "PATCH", Host: "example.com", Path: "/items/1", Status: "204", BytesSent: 180, BytesReceived: 420},
+		},
+		{
+			name:   "unknown method",
+			fields: Fields{"request": "\\x16\\x03\\x01 /", "status": "400", "server_name": "_"},
+			want:   Request{Method: "other", Host: "_", Path: "/", Status: "400"},
+		},
+		{
+			name:   "malformed request",
+			fields: Fields{"request": "\\x16\\x03\\x01", "status": "400", "body_bytes_sent": "-1"},
+			want:   Request{Status: "400"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.fields.Request(); got != tt.want {
+				t.Errorf("Request() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchRoute(t *testing.T) {
+	t.Parallel()
+
+	routes := []Route{
+		{Name: "users", Regexp: regexp.MustCompile(`^/api/users(/|$)`)},
+		{Name: "api", Regexp: regexp.MustCompile(`^/api/`)},
+	}
+
+	tests := []struct {
+		path   string
+		want   string
+		routes []Route
+	}{
+		{path: "/api/users/1", routes: routes, want: "users"},
+		{path: "/api/items", routes: routes, want: "api"},
+		{path: "/index.html", routes: routes, want: "other"},
+		{path: "/index.html", want: ""},
+	}
+	for _, tt := range tests {
+		if got := MatchRoute(tt.routes, tt.path); got != tt.want {
+			t.Errorf("MatchRoute(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMatchHost(t *testing.T) {
+	t.Parallel()
+
+	hosts := []string{"Example.com", "*.api.example.com", "[::1]"}
+
+	tests := []struct {
+		host  string
+		want  string
+		hosts []string
+	}{
+		{host: "example.com", hosts: hosts, want: "example.com"},
+		{host: "example.com:8080", hosts: hosts, want: "example.com"},
+		{host: "v1.api.example.com", hosts: hosts, want: "*.api.example.com"},
+		{host: "api.example.com", hosts: hosts, want: "other"},
+		{host: "[::1]:8080", hosts: hosts, want: "[::1]"},
+		{host: "[::1]", hosts: hosts, want: "[::1]"},
+		{host: "attacker.test", hosts: hosts, want: "other"},
+		{host: "", hosts: hosts, want: "other"},
+		{host: "example.com", want: ""},
+	}
+	for _, tt := range tests {
+		if got := MatchHost(tt.hosts, tt.host); got != tt.want {
+			t.Errorf("MatchHost(%q) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package accesslog
+
+import (
+	"strconv"
+	"strings"
+)
+
+// UpstreamTime is the time spent with one of the upstream servers tried for a
+// request.
+type UpstreamTime struct {
+	// Addr is the address of the server from $upstream_addr.
+	Addr    string
+	Seconds float64
+}
+
+// UpstreamTimes returns the times of an upstream time variable, such as
+// $upstream_response_time, paired with the servers of $upstream_addr. The
+// times of servers which were not reached, written as "-", are skipped.
+func (f Fields) UpstreamTimes(name string) []UpstreamTime {
+	values := splitUpstreamValues(f[name])
+	if len(values) == 0 {
+		return nil
+	}
+	addrs := splitUpstreamValues(f["upstream_addr"])
+
+	times := make([]UpstreamTime, 0, len(values))
+	for i, v := range values {
+		seconds, ok := parseSeconds(v)
+		if !ok {
+			continue
+		}
+		t := UpstreamTime{Seconds: seconds}
+		if i < len(addrs) {
+			t.Addr = addrs[i]
+		}
+		times = append(times, t)
+	}
+	return times
+}
+
+// Seconds returns the value of a time variable in seconds, such as
+// $request_time.
+func (f Fields) Seconds(name string) (float64, bool) {
+	return parseSeconds(f[name])
+}
+
+// UpstreamName returns the name of the upstream group the request was passed
+// to, which is $proxy_host for proxy_pass.
+func (f Fields) UpstreamName() string {
+	return f["proxy_host"]
+}
+
+// UpstreamAddr returns the last server of $upstream_addr, which sent the
+// response.
+func (f Fields) UpstreamAddr() string {
+	addrs := splitUpstreamValues(f["upstream_addr"])
+	if len(addrs) == 0 {
+		return ""
+	}
+	return addrs[len(addrs)-1]
+}
+
+// splitUpstreamValues splits the value of an upstream variable. NGINX
+// separates the values of the servers tried with ", " and those of the
+// upstream groups of internal redirects with " : ".
+func splitUpstreamValues(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var values []string
+	for _, group := range strings.Split(s, " : ") {
+		for _, v := range strings.Split(group, ",") {
+			values = append(values, strings.TrimSpace(v))
+		}
+	}
+	return values
+}
+
+func parseSeconds(s string) (float64, bool) {
+	if s == "" || s == "-" {
+		return 0, false
+	}
+	seconds, err := strconv.ParseFloat(s, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return seconds, true
+}
@@ -0,0 +1,74 @@
+package accesslog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFieldsUpstreamTimes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		fields Fields
+		name   string
+		want   []UpstreamTime
+	}{
+		{
+			name:   "single server",
+			fields: Fields{"upstream_addr": "10.0.0.1:8080", "upstream_response_time": "0.012"},
+			want:   []UpstreamTime{{Addr: "10.0.0.1:8080", Seconds: 0.012}},
+		},
+		{
+			name:   "retries",
+			fields: Fields{"upstream_addr": "10.0.0.1:8080, 10.0.0.2:8080", "upstream_response_time": "0.010, 0.200"},
+			want:   []UpstreamTime{{Addr: "10.0.0.1:8080", Seconds: 0.01}, {Addr: "10.0.0.2:8080", Seconds: 0.2}},
+		},
+		{
+			name:   "internal redirect",
+			fields: Fields{"upstream_addr": "10.0.0.1:8080, 10.0.0.2:8080 : 10.0.1.1:9000", "upstream_response_time": "0.001, 0.002 : 0.003"},
+			want:   []UpstreamTime{{Addr: "10.0.0.1:8080", Seconds: 0.001}, {Addr: "10.0.0.2:8080", Seconds: 0.002}, {Addr: "10.0.1.1:9000", Seconds: 0.003}},
+		},
+		{
+			name:   "server not reached",
+			fields: Fields{"upstream_addr": "10.0.0.1:8080, 10.0.0.2:8080", "upstream_response_time": "-, 0.200"},
+			want:   []UpstreamTime{{Addr: "10.0.0.2:8080", Seconds: 0.2}},
+		},
+		{
+			name:   "without address",
+			fields: Fields{"upstream_response_time": "0.5"},
+			want:   []UpstreamTime{{Seconds: 0.5}},
+		},
+		{
+			name:   "without upstream",
+			fields: Fields{"upstream_addr": "", "upstream_response_time": ""},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.fields.UpstreamTimes("upstream_response_time"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UpstreamTimes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldsUpstreamAddr(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{addr: "10.0.0.1:8080", want: "10.0.0.1:8080"},
+		{addr: "10.0.0.1:8080, 10.0.0.2:8080 : unix:/run/app.sock", want: "unix:/run/app.sock"},
+		{addr: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := (Fields{"upstream_addr": tt.addr}).UpstreamAddr(); got != tt.want {
+			t.Errorf("UpstreamAddr() of %q = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Package buildinfo parses the build information NGINX prints with -V.
+package buildinfo
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ErrNoVersion means the output has no version line, such as when the
+// command is not NGINX.
+var ErrNoVersion = errors.New("no version found in the output")
+
+var (
+	// versionRE matches "nginx version: nginx/1.25.3" and the lines of the
+	// forks, such as "Tengine version: Tengine/2.4.0 (nginx/1.21.4)".
+	versionRE = regexp.MustCompile(`(?m)^\S+ version: ([^/\s]+)/(\S+)`)
+	// compilerRE matches "built by gcc 12.2.0 (Debian 12.2.0-14)".
+	compilerRE = regexp.MustCompile(`(?m)^built by (.+?)\s*$`)
+	// openSSLRE matches "built with OpenSSL 3.0.2 15 Mar 2022", optionally
+	// followed by "(running with OpenSSL 3.0.13 30 Jan 2024)".
+	openSSLRE = regexp.MustCompile(`(?m)^built with (\S+ \S+)`)
+)
+
+// Info is the build information of NGINX.
+type Info struct {
+	// Product is "nginx" or the name of a fork, such as "openresty".
+	Product  string
+	Version  string
+	Compiler string
+	// OpenSSL is the TLS library NGINX was built with, such as
+	// "OpenSSL 3.0.2".
+	OpenSSL          string
+	ConfigureOptions []string
+	Modules          []Module
+}
+
+// Module is a module compiled into NGINX or built as a dynamic module.
+type Module struct {
+	// Name is the name of a --with flag, such as "http_ssl_module", or the
+	// directory of an --add-module flag.
+	Name string
+	// Dynamic is set for the modules loaded with load_module.
+	Dynamic bool
+	// ThirdParty is set for the modules of --add-module and
+	// --add-dynamic-module flags.
+	ThirdParty bool
+}
+
+// Parse parses the output of nginx -V.
+func Parse(output string) (Info, error) {
+	m := versionRE.FindStringSubmatch(output)
+	if m == nil {
+		return Info{}, ErrNoVersion
+	}
+	info := Info{Product: m[1], Version: m[2]}
+	if m := compilerRE.FindStringSubmatch(output); m != nil {
+		info.Compiler = m[1]
+	}
+	if m := openSSLRE.FindStringSubmatch(output); m != nil {
+		info.OpenSSL = m[1]
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if args, ok := strings.CutPrefix(strings.TrimSpace(line), "configure arguments:"); ok {
+			info.ConfigureOptions = splitArguments(args)
+		}
+	}
+	info.Modules = Modules(info.ConfigureOptions)
+	return info, nil
+}
+
+// Modules returns the modules of the configure options. A --with flag
+// without a value, or with the value "dynamic", is a module, while others,
+// such as --with-cc-opt, set options.
+func Modules(options []string) []Module {
+	var modules []Module
+	for _, option := range options {
+		name, value, hasValue := strings.Cut(option, "=")
+		switch {
+		case strings.HasPrefix(name, "--with-") && (!hasValue || value == "dynamic"):
+			modules = append(modules, Module{Name: strings.TrimPrefix(name, "--with-"), Dynamic: hasValue})
+		case name == "--add-module" || name == "--add-dynamic-module":
+			modules = append(modules, Module{
+				Name:       filepath.Base(strings.TrimRight(value, "/")),
+				Dynamic:    name == "--add-dynamic-module",
+				ThirdParty: true,
+			})
+		}
+	}
+	return modules
+}
+
+// splitArguments splits the configure arguments like a shell, as the values
+// of options such as --with-cc-opt are quoted.
+func splitArguments(s string) []string {
+	var args []string
+	var b strings.Builder
+	var quote rune
+	inArg := false
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			b.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, b.String())
+				b.Reset()
+				inArg = false
+			}
+		default:
+			b.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, b.String())
+	}
+	return args
+}
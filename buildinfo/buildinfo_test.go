@@ -0,0 +1,85 @@
+package buildinfo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		output  string
+		want    Info
+		wantErr error
+	}{
+		{
+			name: "nginx",
+			output: `nginx version: nginx/1.25.3
+built by gcc 12.2.0 (Debian 12.2.0-14)
+built with OpenSSL 3.0.11 19 Sep 2023
+TLS SNI support enabled
+configure arguments: --prefix=/etc/nginx --with-compat --with-http_ssl_module --with-stream=dynamic --with-cc-opt='-g -O2 -fstack-protector-strong' --add-dynamic-module=/build/njs/nginx/ --add-module=../ngx_brotli
+`,
+			want: Info{
+				Product:  "nginx",
+				Version:  "1.25.3",
+				Compiler: "gcc 12.2.0 (Debian 12.2.0-14)",
+				OpenSSL:  "OpenSSL 3.0.11",
+				ConfigureOptions: []string{
+					"--prefix=/etc/nginx", "--with-compat", "--with-http_ssl_module", "--with-stream=dynamic",
+					"--with-cc-opt=-g -O2 -fstack-protector-strong", "--add-dynamic-module=/build/njs/nginx/", "--add-module=../ngx_brotli",
+				},
+				Modules: []Module{
+					{Name: "compat"},
+					{Name: "http_ssl_module"},
+					{Name: "stream", Dynamic: true},
+					{Name: "nginx", Dynamic: true, ThirdParty: true},
+					{Name: "ngx_brotli", ThirdParty: true},
+				},
+			},
+		},
+		{
+			name: "openresty running with another OpenSSL",
+			output: `nginx version: openresty/1.21.4.3
+built by clang 15.0.0
+built with OpenSSL 1.1.1w  11 Sep 2023 (running with OpenSSL 3.0.13 30 Jan 2024)
+configure arguments: --prefix=/usr/local/openresty/nginx --with-pcre-jit
+`,
+			want: Info{
+				Product:          "openresty",
+				Version:          "1.21.4.3",
+				Compiler:         "clang 15.0.0",
+				OpenSSL:          "OpenSSL 1.1.1w",
+				ConfigureOptions: []string{"--prefix=/usr/local/openresty/nginx", "--with-pcre-jit"},
+				Modules:          []Module{{Name: "pcre-jit"}},
+			},
+		},
+		{
+			name:   "Tengine without configure arguments",
+			output: "Tengine version: Tengine/2.4.0 (nginx/1.21.4)\n",
+			want:   Info{Product: "Tengine", Version: "2.4.0"},
+		},
+		{
+			name:    "not NGINX",
+			output:  "sh: nginx: not found\n",
+			wantErr: ErrNoVersion,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Parse(tt.output)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// AngieClient allows you to fetch Angie metrics from its JSON status API.
+type AngieClient struct {
+	httpClient  *http.Client
+	apiEndpoint string
+}
+
+// AngieStats represents the Angie status API.
+type AngieStats struct {
+	Slabs       map[string]AngieSlab     `json:"slabs"`
+	Resolvers   map[string]AngieResolver `json:"resolvers"`
+	Angie       AngieInfo                `json:"angie"`
+	HTTP        AngieHTTP                `json:"http"`
+	Connections AngieConnections         `json:"connections"`
+}
+
+// AngieInfo represents general information about Angie.
+type AngieInfo struct {
+	Version    string `json:"version"`
+	Address    string `json:"address"`
+	Generation int64  `json:"generation"`
+}
+
+// AngieConnections represents client connections.
+type AngieConnections struct {
+	Accepted int64 `json:"accepted"`
+	Dropped  int64 `json:"dropped"`
+	Active   int64 `json:"active"`
+	Idle     int64 `json:"idle"`
+}
+
+// AngieSlab represents a shared memory zone.
+type AngieSlab struct {
+	Slots map[string]AngieSlabSlot `json:"slots"`
+	Pages AngieSlabPages           `json:"pages"`
+}
+
+// AngieSlabPages represents the memory pages of a shared memory zone.
+type AngieSlabPages struct {
+	Used int64 `json:"used"`
+	Free int64 `json:"free"`
+}
+
+// AngieSlabSlot represents the memory slots of a given size of a shared memory zone.
+type AngieSlabSlot struct {
+	Used  int64 `json:"used"`
+	Free  int64 `json:"free"`
+	Reqs  int64 `json:"reqs"`
+	Fails int64 `json:"fails"`
+}
+
+// AngieHTTP represents the http sections of the status API.
+type AngieHTTP struct {
+	ServerZones   map[string]AngieServerZone   `json:"server_zones"`
+	LocationZones map[string]AngieLocationZone `json:"location_zones"`
+	Upstreams     map[string]AngieUpstream     `json:"upstreams"`
+	Caches        map[string]AngieCache        `json:"caches"`
+	LimitConns    map[string]AngieLimitConn    `json:"limit_conns"`
+	LimitReqs     map[string]AngieLimitReq     `json:"limit_reqs"`
+}
+
+// AngieServerZone represents an http server zone.
+type AngieServerZone struct {
+	SSL       *AngieSSL        `json:"ssl"`
+	Responses map[string]int64 `json:"responses"`
+	Requests  AngieRequests    `json:"requests"`
+	Data      AngieData        `json:"data"`
+}
+
+// AngieLocationZone represents an http location zone.
+type AngieLocationZone struct {
+	Responses map[string]int64 `json:"responses"`
+	Requests  AngieRequests    `json:"requests"`
+	Data      AngieData        `json:"data"`
+}
+
+// AngieSSL represents the SSL handshakes of a zone.
+type AngieSSL struct {
+	Handshaked int64 `json:"handshaked"`
+	Reuses     int64 `json:"reuses"`
+	Timedout   int64 `json:"timedout"`
+	Failed     int64 `json:"failed"`
+}
+
+// AngieRequests represents the requests of a zone.
+type AngieRequests struct {
+	Total      int64 `json:"total"`
+	Processing int64 `json:"processing"`
+	Discarded  int64 `json:"discarded"`
+}
+
+// AngieData represents the bytes transferred by a zone or a peer.
+type AngieData struct {
+	Received int64 `json:"received"`
+	Sent     int64 `json:"sent"`
+}
+
+// AngieUpstream represents an http upstream.
+type AngieUpstream struct {
+	Peers     map[string]AngieUpstreamPeer `json:"peers"`
+	Keepalive int64                        `json:"keepalive"`
+}
+
+// AngieUpstreamPeer represents a server of an http upstream.
+type AngieUpstreamPeer struct {
+	Responses map[string]int64        `json:"responses"`
+	Server    string                  `json:"server"`
+	State     string                  `json:"state"`
+	Selected  AngieSelected           `json:"selected"`
+	Data      AngieData               `json:"data"`
+	Health    AngieUpstreamPeerHealth `json:"health"`
+	MaxConns  int64                   `json:"max_conns"`
+	Weight    int64                   `json:"weight"`
+	Backup    bool                    `json:"backup"`
+}
+
+// AngieSelected represents how often a peer was selected to process requests.
+type AngieSelected struct {
+	Current int64 `json:"current"`
+	Total   int64 `json:"total"`
+}
+
+// AngieUpstreamPeerHealth represents the health of a peer.
+type AngieUpstreamPeerHealth struct {
+	Fails       int64 `json:"fails"`
+	Unavailable int64 `json:"unavailable"`
+	Downtimes   int64 `json:"downtimes"`
+	Downtime    int64 `json:"downtime"`
+}
+
+// AngieCache represents an http cache.
+type AngieCache struct {
+	MaxSize     *int64          `json:"max_size"`
+	Hit         AngieCacheStats `json:"hit"`
+	Stale       AngieCacheStats `json:"stale"`
+	Updating    AngieCacheStats `json:"updating"`
+	Revalidated AngieCacheStats `json:"revalidated"`
+	Miss        AngieCacheStats `json:"miss"`
+	Expired     AngieCacheStats `json:"expired"`
+	Bypass      AngieCacheStats `json:"bypass"`
+	Size        int64           `json:"size"`
+	Cold        bool            `json:"cold"`
+}
+
+// AngieCacheStats represents the responses served by a cache with a given status.
+type AngieCacheStats struct {
+	Responses        int64 `json:"responses"`
+	Bytes            int64 `json:"bytes"`
+	ResponsesWritten int64 `json:"responses_written"`
+	BytesWritten     int64 `json:"bytes_written"`
+}
+
+// AngieLimitConn represents a limit_conn zone.
+type AngieLimitConn struct {
+	Passed    int64 `json:"passed"`
+	Skipped   int64 `json:"skipped"`
+	Rejected  int64 `json:"rejected"`
+	Exhausted int64 `json:"exhausted"`
+}
+
+// AngieLimitReq represents a limit_req zone.
+type AngieLimitReq struct {
+	Passed    int64 `json:"passed"`
+	Skipped   int64 `json:"skipped"`
+	Delayed   int64 `json:"delayed"`
+	Rejected  int64 `json:"rejected"`
+	Exhausted int64 `json:"exhausted"`
+}
+
+// AngieResolver represents a resolver zone.
+type AngieResolver struct {
+	Queries   AngieResolverQueries   `json:"queries"`
+	Responses AngieResolverResponses `json:"responses"`
+}
+
+// AngieResolverQueries represents the queries of a resolver zone.
+type AngieResolverQueries struct {
+	Name int64 `json:"name"`
+	Srv  int64 `json:"srv"`
+	Addr int64 `json:"addr"`
+}
+
+// AngieResolverResponses represents the responses received by a resolver zone.
+type AngieResolverResponses struct {
+	Success       int64 `json:"success"`
+	Timedout      int64 `json:"timedout"`
+	FormatError   int64 `json:"format_error"`
+	ServerFailure int64 `json:"server_failure"`
+	NotFound      int64 `json:"not_found"`
+	Unimplemented int64 `json:"unimplemented"`
+	Refused       int64 `json:"refused"`
+	Other         int64 `json:"other"`
+}
+
+// NewAngieClient creates an AngieClient. The apiEndpoint is the location of
+// the api directive, for example http://127.0.0.1:8080/status/.
+func NewAngieClient(httpClient *http.Client, apiEndpoint string) *AngieClient {
+	client := &AngieClient{
+		apiEndpoint: apiEndpoint,
+		httpClient:  httpClient,
+	}
+
+	return client
+}
+
+// GetStats fetches the Angie metrics. The request is canceled when ctx is done.
+func (client *AngieClient) GetStats(ctx context.Context) (*AngieStats, error) {
+	var stats AngieStats
+	if err := getJSON(ctx, client.httpClient, client.apiEndpoint, &stats); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestAngieClientGetStats(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/status/" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, filepath.Join("testdata", "angie", "status.json"))
+	}))
+	defer srv.Close()
+
+	stats, err := NewAngieClient(srv.Client(), srv.URL+"/status/").GetStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetStats() returned error: %v", err)
+	}
+
+	if stats.Angie.Version != "1.5.0" {
+		t.Errorf("Angie.Version = %q, want %q", stats.Angie.Version, "1.5.0")
+	}
+	if stats.Connections.Accepted != 2257 {
+		t.Errorf("Connections.Accepted = %d, want 2257", stats.Connections.Accepted)
+	}
+	zone := stats.HTTP.ServerZones["www.example.com"]
+	if zone.SSL == nil || zone.SSL.Handshaked != 4174 {
+		t.Errorf("ServerZones[www.example.com].SSL = %+v, want 4174 handshakes", zone.SSL)
+	}
+	if zone.Responses["404"] != 4 {
+		t.Errorf("ServerZones[www.example.com].Responses[404] = %d, want 4", zone.Responses["404"])
+	}
+	if peer := stats.HTTP.Upstreams["backend"].Peers["192.168.16.4:80"]; peer.Selected.Total != 232 || peer.State != "up" {
+		t.Errorf("Upstreams[backend].Peers[192.168.16.4:80] = %+v, want 232 selections in state up", peer)
+	}
+	if cache := stats.HTTP.Caches["cache"]; cache.MaxSize != nil || cache.Miss.BytesWritten != 512 {
+		t.Errorf("Caches[cache] = %+v, want no max size and 512 bytes written on miss", cache)
+	}
+	if stats.Slabs["cache"].Slots["64"].Free != 63 {
+		t.Errorf("Slabs[cache].Slots[64].Free = %d, want 63", stats.Slabs["cache"].Slots["64"].Free)
+	}
+	if stats.Resolvers["resolver_zone"].Responses.NotFound != 1 {
+		t.Errorf("Resolvers[resolver_zone].Responses.NotFound = %d, want 1", stats.Resolvers["resolver_zone"].Responses.NotFound)
+	}
+
+	_, err = NewAngieClient(srv.Client(), srv.URL+"/api/").GetStats(context.Background())
+	if got := ErrorReason(err); got != ReasonHTTPStatus {
+		t.Errorf("ErrorReason() = %q for a missing API, want %q", got, ReasonHTTPStatus)
+	}
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// API is the kind of status API served by an NGINX endpoint.
+type API string
+
+const (
+	// APIStubStatus is the stub_status page of NGINX.
+	APIStubStatus API = "oss"
+	// APIPlus is the NGINX Plus API.
+	APIPlus API = "plus"
+)
+
+// ErrUnknownAPI is returned by DetectAPI when the response matches neither the
+// stub_status page nor the NGINX Plus API.
+var ErrUnknownAPI = errors.New("response is neither a stub_status page nor the NGINX Plus API")
+
+// DetectAPI requests apiEndpoint and reports which status API it serves. The
+// NGINX Plus API responds with the JSON array of its supported versions, the
+// stub_status page with its plain text layout. The request is canceled when
+// ctx is done.
+func DetectAPI(ctx context.Context, httpClient *http.Client, apiEndpoint string) (API, error) {
+	body, err := getBody(ctx, httpClient, apiEndpoint)
+	if err != nil {
+		return "", err
+	}
+
+	var versions []int
+	if err := json.Unmarshal(body, &versions); err == nil && len(versions) > 0 {
+		return APIPlus, nil
+	}
+	if _, err := ParseStubStats(bytes.NewReader(body)); err == nil {
+		return APIStubStatus, nil
+	}
+
+	return "", &Error{
+		Err:    fmt.Errorf("failed to detect the API of %v: %w", apiEndpoint, ErrUnknownAPI),
+		Reason: ReasonParse,
+	}
+}
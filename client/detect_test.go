@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDetectAPI(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		body       string
+		status     int
+		want       API
+		wantErr    error
+		wantReason string
+	}{
+		{
+			name:   "stub_status page",
+			body:   validStabStats,
+			status: http.StatusOK,
+			want:   APIStubStatus,
+		},
+		{
+			name:   "NGINX Plus API",
+			body:   "[1,2,3,4,5,6,7,8,9]",
+			status: http.StatusOK,
+			want:   APIPlus,
+		},
+		{
+			name:       "empty versions array",
+			body:       "[]",
+			status:     http.StatusOK,
+			wantErr:    ErrUnknownAPI,
+			wantReason: ReasonParse,
+		},
+		{
+			name:       "unrelated page",
+			body:       "<html>Welcome to nginx!</html>",
+			status:     http.StatusOK,
+			wantErr:    ErrUnknownAPI,
+			wantReason: ReasonParse,
+		},
+		{
+			name:       "error status",
+			body:       "not found",
+			status:     http.StatusNotFound,
+			wantReason: ReasonHTTPStatus,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			got, err := DetectAPI(context.Background(), srv.Client(), srv.URL)
+			if tt.wantReason != "" {
+				if err == nil {
+					t.Fatalf("DetectAPI() returned no error, want reason %q", tt.wantReason)
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("DetectAPI() error = %v, want %v", err, tt.wantErr)
+				}
+				if got := ErrorReason(err); got != tt.wantReason {
+					t.Errorf("ErrorReason(DetectAPI() error) = %q, want %q", got, tt.wantReason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DetectAPI() returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DetectAPI() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
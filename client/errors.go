@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+)
+
+// Reasons of the errors returned by the clients, as reported by ErrorReason.
+const (
+	// ReasonDial means the connection to NGINX could not be established.
+	ReasonDial = "dial"
+	// ReasonTLS means the TLS handshake with NGINX failed.
+	ReasonTLS = "tls"
+	// ReasonTimeout means NGINX did not respond in time.
+	ReasonTimeout = "timeout"
+	// ReasonHTTPStatus means NGINX responded with a status other than 200 OK.
+	ReasonHTTPStatus = "http_status"
+	// ReasonRead means the response could not be received.
+	ReasonRead = "read"
+	// ReasonParse means the response could not be parsed.
+	ReasonParse = "parse"
+)
+
+// Error is an error of a request to NGINX whose reason is known when it is
+// returned.
+type Error struct {
+	Err    error
+	Reason string
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrorReason classifies an error returned by the clients. Errors of the
+// transport that are neither timeouts nor failures to connect count as
+// failures to read the response.
+func ErrorReason(err error) string {
+	var clientErr *Error
+	var netErr net.Error
+	var opErr *net.OpError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled):
+		return ReasonTimeout
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ReasonTimeout
+	case errors.As(err, &clientErr):
+		return clientErr.Reason
+	case isTLSError(err):
+		return ReasonTLS
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return ReasonDial
+	}
+	return ReasonRead
+}
+
+func isTLSError(err error) bool {
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	var opErr *net.OpError
+	return errors.As(err, &certErr) || errors.As(err, &recordErr) || errors.As(err, &alertErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) ||
+		// Alerts sent by the server during the handshake.
+		(errors.As(err, &opErr) && opErr.Op == "remote error")
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestErrorReason(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/slow":
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		default:
+			_, _ = io.WriteString(w, "<html>Welcome to nginx!</html>")
+		}
+	})
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	tlsSrv := httptest.NewTLSServer(handler)
+	t.Cleanup(tlsSrv.Close)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	closedAddr := listener.Addr().String()
+	listener.Close()
+
+	tests := []struct {
+		name     string
+		endpoint string
+		want     string
+		// timeout is only short for the timeouts, so the other requests
+		// complete on slow machines and under the race detector.
+		timeout time.Duration
+	}{
+		{
+			name:     "connection refused",
+			endpoint: "http://" + closedAddr + "/stub_status",
+			want:     ReasonDial,
+		},
+		{
+			name:     "untrusted certificate",
+			endpoint: tlsSrv.URL + "/stub_status",
+			want:     ReasonTLS,
+		},
+		{
+			name:     "slow response",
+			endpoint: srv.URL + "/slow",
+			want:     ReasonTimeout,
+			timeout:  100 * time.Millisecond,
+		},
+		{
+			name:     "not found",
+			endpoint: srv.URL + "/missing",
+			want:     ReasonHTTPStatus,
+		},
+		{
+			name:     "unrelated page",
+			endpoint: srv.URL + "/stub_status",
+			want:     ReasonParse,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			timeout := tt.timeout
+			if timeout == 0 {
+				timeout = 10 * time.Second
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			_, err := NewNginxClient(&http.Client{}, tt.endpoint).GetStubStatsContext(ctx)
+			if err == nil {
+				t.Fatal("GetStubStatsContext() returned no error")
+			}
+			if got := ErrorReason(err); got != tt.want {
+				t.Errorf("ErrorReason(%v) = %q, want %q", err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+)
+
+// ResponseInfo describes the HTTP response a status page was read from.
+type ResponseInfo struct {
+	// Server is the Server header, such as "nginx/1.25.3", or "nginx" with
+	// server_tokens off.
+	Server string
+	// RemoteAddr is the address of the connection the response was read
+	// from, which tells the instance of a host name resolving to several.
+	RemoteAddr string
+	// TLSVersion and TLSCipher describe the TLS connection of https
+	// endpoints. They are empty for http endpoints.
+	TLSVersion string
+	TLSCipher  string
+}
+
+// withResponseInfo returns a context whose requests record the address of
+// their connection in info.
+func withResponseInfo(ctx context.Context, info *ResponseInfo) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(conn httptrace.GotConnInfo) {
+			if addr := conn.Conn.RemoteAddr(); addr != nil {
+				info.RemoteAddr = addr.String()
+			}
+		},
+	})
+}
+
+// readResponseInfo records the headers and the TLS connection of resp in
+// info.
+func readResponseInfo(resp *http.Response, info *ResponseInfo) {
+	info.Server = resp.Header.Get("Server")
+	if resp.TLS != nil {
+		info.TLSVersion = tls.VersionName(resp.TLS.Version)
+		info.TLSCipher = tls.CipherSuiteName(resp.TLS.CipherSuite)
+	}
+}
+
+// getBody requests endpoint with httpClient and returns the body of the
+// response. The request is canceled when ctx is done.
+func getBody(ctx context.Context, httpClient *http.Client, endpoint string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a get request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %v: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &Error{
+			Err:    fmt.Errorf("expected %v response, got %v", http.StatusOK, resp.StatusCode),
+			Reason: ReasonHTTPStatus,
+		}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &Error{
+			Err:    fmt.Errorf("failed to read the response body: %w", err),
+			Reason: ReasonRead,
+		}
+	}
+
+	return body, nil
+}
+
+// getJSON requests endpoint with httpClient and decodes the JSON response
+// into v. The request is canceled when ctx is done.
+func getJSON(ctx context.Context, httpClient *http.Client, endpoint string, v any) error {
+	body, err := getBody(ctx, httpClient, endpoint)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return &Error{
+			Err:    fmt.Errorf("failed to parse response body %q: %w", string(body), err),
+			Reason: ReasonParse,
+		}
+	}
+
+	return nil
+}
@@ -1,18 +1,24 @@
 package client
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
-const templateMetrics string = `Active connections: %d
-server accepts handled requests
-%d %d %d
-Reading: %d Writing: %d Waiting: %d
-`
+// Sentinel errors of ParseStubStats, wrapped with the name of the field.
+var (
+	// ErrMissingField means a field required on every stub_status page is missing.
+	ErrMissingField = errors.New("missing stub_status field")
+	// ErrInvalidValue means a field is not a non-negative integer.
+	ErrInvalidValue = errors.New("invalid stub_status value")
+)
 
 // NginxClient allows you to fetch NGINX metrics from the stub_status page.
 type NginxClient struct {
@@ -22,6 +28,12 @@ type NginxClient struct {
 
 // StubStats represents NGINX stub_status metrics.
 type StubStats struct {
+	// Response describes the response the stats were read from. It is nil
+	// for stats parsed by ParseStubStats.
+	Response *ResponseInfo
+	// RequestTime is the total time spent processing requests in
+	// milliseconds, reported by Tengine. It is nil when the page lacks it.
+	RequestTime *int64
 	Connections StubConnections
 	Requests    int64
 }
@@ -48,10 +60,17 @@ func NewNginxClient(httpClient *http.Client, apiEndpoint string) *NginxClient {
 
 // GetStubStats fetches the stub_status metrics.
 func (client *NginxClient) GetStubStats() (*StubStats, error) {
-	ctx, cancel := context.WithCancel(context.Background())
+	return client.GetStubStatsContext(context.Background())
+}
+
+// GetStubStatsContext fetches the stub_status metrics. The request is canceled
+// when ctx is done.
+func (client *NginxClient) GetStubStatsContext(ctx context.Context) (*StubStats, error) {
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, client.apiEndpoint, nil)
+	var info ResponseInfo
+	req, err := http.NewRequestWithContext(withResponseInfo(ctx, &info), http.MethodGet, client.apiEndpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a get request: %w", err)
 	}
@@ -60,36 +79,131 @@ func (client *NginxClient) GetStubStats() (*StubStats, error) {
 		return nil, fmt.Errorf("failed to get %v: %w", client.apiEndpoint, err)
 	}
 	defer resp.Body.Close()
+	readResponseInfo(resp, &info)
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("expected %v response, got %v", http.StatusOK, resp.StatusCode)
+		return nil, &Error{
+			Err:    fmt.Errorf("expected %v response, got %v", http.StatusOK, resp.StatusCode),
+			Reason: ReasonHTTPStatus,
+		}
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read the response body: %w", err)
+		return nil, &Error{
+			Err:    fmt.Errorf("failed to read the response body: %w", err),
+			Reason: ReasonRead,
+		}
 	}
 
 	r := bytes.NewReader(body)
-	stats, err := parseStubStats(r)
+	stats, err := ParseStubStats(r)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse response body %q: %w", string(body), err)
+		return nil, &Error{
+			Err:    fmt.Errorf("failed to parse response body %q: %w", string(body), err),
+			Reason: ReasonParse,
+		}
 	}
+	stats.Response = &info
 
 	return stats, nil
 }
 
-func parseStubStats(r io.Reader) (*StubStats, error) {
+// ParseStubStats parses a stub_status page. The parser works line by line and
+// accepts any whitespace between the fields, additional columns after
+// "server accepts handled requests" and additional fields next to "Reading",
+// "Writing" and "Waiting", as some NGINX forks report. Unknown columns, fields
+// and lines are ignored.
+func ParseStubStats(r io.Reader) (*StubStats, error) {
 	var s StubStats
-	if _, err := fmt.Fscanf(r, templateMetrics,
-		&s.Connections.Active,
-		&s.Connections.Accepted,
-		&s.Connections.Handled,
-		&s.Requests,
-		&s.Connections.Reading,
-		&s.Connections.Writing,
-		&s.Connections.Waiting); err != nil {
-		return nil, fmt.Errorf("failed to scan template metrics: %w", err)
+	var requestTime int64
+	values := map[string]*int64{
+		"Active connections": &s.Connections.Active,
+		"accepts":            &s.Connections.Accepted,
+		"handled":            &s.Connections.Handled,
+		"requests":           &s.Requests,
+		"Reading":            &s.Connections.Reading,
+		"Writing":            &s.Connections.Writing,
+		"Waiting":            &s.Connections.Waiting,
+		"request_time":       &requestTime,
 	}
+	found := make(map[string]bool, len(values))
+
+	set := func(name, value string) error {
+		v, ok := values[name]
+		if !ok {
+			return nil
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			return fmt.Errorf("%w: %s %q", ErrInvalidValue, name, value)
+		}
+		*v = n
+		found[name] = true
+		return nil
+	}
+
+	// columns holds the names of the values expected on the next line.
+	var columns []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch {
+		case columns != nil:
+			if len(fields) < len(columns) {
+				return nil, fmt.Errorf("%w: expected %d values for %q, got %d", ErrMissingField, len(columns), strings.Join(columns, " "), len(fields))
+			}
+			for i, name := range columns {
+				if err := set(name, fields[i]); err != nil {
+					return nil, err
+				}
+			}
+			columns = nil
+		case len(fields) >= 2 && fields[0] == "Active" && fields[1] == "connections:":
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("%w: Active connections", ErrMissingField)
+			}
+			if err := set("Active connections", fields[2]); err != nil {
+				return nil, err
+			}
+		case fields[0] == "server":
+			columns = fields[1:]
+		default:
+			// Fields are "Name: value" pairs, possibly written as "Name:value".
+			for i := 0; i < len(fields); i++ {
+				name, value, ok := strings.Cut(fields[i], ":")
+				if !ok {
+					continue
+				}
+				if value == "" {
+					if i+1 == len(fields) {
+						break
+					}
+					i++
+					value = fields[i]
+				}
+				if err := set(name, value); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stub_status: %w", err)
+	}
+
+	for _, name := range []string{"Active connections", "accepts", "handled", "requests", "Reading", "Writing", "Waiting"} {
+		if !found[name] {
+			return nil, fmt.Errorf("%w: %s", ErrMissingField, name)
+		}
+	}
+	if found["request_time"] {
+		s.RequestTime = &requestTime
+	}
+
 	return &s, nil
 }
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+
+	plusclient "github.com/nginxinc/nginx-plus-go-client/client"
+)
+
+// NginxPlusClient allows you to fetch NGINX Plus metrics from the NGINX Plus API.
+// Unlike the NGINX Plus API client it wraps, it binds the requests to a context.
+type NginxPlusClient struct {
+	httpClient  *http.Client
+	apiEndpoint string
+}
+
+// NewNginxPlusClient creates an NginxPlusClient.
+func NewNginxPlusClient(httpClient *http.Client, apiEndpoint string) *NginxPlusClient {
+	client := &NginxPlusClient{
+		apiEndpoint: apiEndpoint,
+		httpClient:  httpClient,
+	}
+
+	return client
+}
+
+// GetStats fetches the NGINX Plus metrics. The requests are canceled when ctx is done.
+func (client *NginxPlusClient) GetStats(ctx context.Context) (*plusclient.Stats, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	httpClient := *client.httpClient
+	rt := httpClient.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	ctxRT := &contextRoundTripper{ctx: ctx, rt: rt}
+	httpClient.Transport = ctxRT
+
+	plusClient, err := plusclient.NewNginxClient(client.apiEndpoint, plusclient.WithHTTPClient(&httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create NGINX Plus API client: %w", err)
+	}
+	stats, err := plusClient.GetStats()
+	if err != nil {
+		// The NGINX Plus API client formats the status of failed responses
+		// into its errors, so the status is taken from the last response.
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr) || errors.As(err, &typeErr):
+			return nil, &Error{Err: err, Reason: ReasonParse}
+		case ctxRT.status.Load() != 0 && ctxRT.status.Load() != http.StatusOK:
+			return nil, &Error{Err: err, Reason: ReasonHTTPStatus}
+		}
+		return nil, err
+	}
+	return stats, nil
+}
+
+// contextRoundTripper cancels the requests sent by the NGINX Plus API client,
+// which does not accept a context, when ctx is done. The timeouts of the
+// requests still apply. It records the status of the last response, as the
+// client may send its requests concurrently.
+type contextRoundTripper struct {
+	ctx    context.Context //nolint:containedctx // the client creates its requests without a context
+	rt     http.RoundTripper
+	status atomic.Int64
+}
+
+func (rt *contextRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancelCause(req.Context())
+	stop := context.AfterFunc(rt.ctx, func() {
+		cancel(context.Cause(rt.ctx))
+	})
+	resp, err := rt.rt.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		stop()
+		cancel(nil)
+		return nil, err
+	}
+	rt.status.Store(int64(resp.StatusCode))
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: func() {
+		stop()
+		cancel(nil)
+	}}
+	return resp, nil
+}
+
+// releasingBody releases the context of a request when its response body is
+// closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNginxPlusClientGetStatsCanceled(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := NewNginxPlusClient(srv.Client(), srv.URL+"/api").GetStats(ctx)
+	if err == nil {
+		t.Fatal("GetStats() returned no error for a canceled context")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("GetStats() returned after %v, want the context deadline to cancel the request", elapsed)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestContextRoundTripperReleasesRequest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err  error
+		name string
+	}{
+		{name: "response body closed"},
+		{name: "round trip failed", err: errors.New("connection refused")},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var reqCtx context.Context
+			rt := &contextRoundTripper{ctx: context.Background(), rt: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				reqCtx = req.Context()
+				if tt.err != nil {
+					return nil, tt.err
+				}
+				return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(""))}, nil
+			})}
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost/api", nil)
+			resp, err := rt.RoundTrip(req)
+			if tt.err == nil {
+				if err != nil {
+					t.Fatalf("RoundTrip() returned error: %v", err)
+				}
+				if reqCtx.Err() != nil {
+					t.Fatalf("RoundTrip() canceled the request before the body was closed")
+				}
+				resp.Body.Close()
+				if got := rt.status.Load(); got != http.StatusNotFound {
+					t.Errorf("RoundTrip() recorded status %d, want %d", got, http.StatusNotFound)
+				}
+			} else if err == nil {
+				t.Fatal("RoundTrip() returned no error")
+			}
+			if reqCtx.Err() == nil {
+				t.Errorf("RoundTrip() did not release the context of the request")
+			}
+		})
+	}
+}
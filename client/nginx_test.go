@@ -2,6 +2,15 @@ package client
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -38,14 +47,146 @@ func TestParseStubStatsValidInput(t *testing.T) {
 
 	for _, test := range tests {
 		r := bytes.NewReader(test.input)
-		result, err := parseStubStats(r)
+		result, err := ParseStubStats(r)
 
 		if err != nil && !test.expectedError {
-			t.Errorf("parseStubStats() returned error for valid input %q: %v", string(test.input), err)
+			t.Errorf("ParseStubStats() returned error for valid input %q: %v", string(test.input), err)
 		}
 
-		if !test.expectedError && test.expectedResult != *result {
-			t.Errorf("parseStubStats() result %v != expected %v for input %q", result, test.expectedResult, test.input)
+		if !test.expectedError && !reflect.DeepEqual(test.expectedResult, *result) {
+			t.Errorf("ParseStubStats() result %v != expected %v for input %q", result, test.expectedResult, test.input)
 		}
 	}
 }
+
+func TestParseStubStatsFixtures(t *testing.T) {
+	t.Parallel()
+
+	requestTime := int64(98211)
+	stats := StubStats{
+		Connections: StubConnections{
+			Active:   1457,
+			Accepted: 6717066,
+			Handled:  6717066,
+			Reading:  1,
+			Writing:  8,
+			Waiting:  1448,
+		},
+		Requests: 65844359,
+	}
+	statsWithRequestTime := stats
+	statsWithRequestTime.RequestTime = &requestTime
+
+	tests := []struct {
+		fixture string
+		want    StubStats
+		wantErr error
+	}{
+		{fixture: "nginx.txt", want: stats},
+		{fixture: "request_time.txt", want: statsWithRequestTime},
+		{fixture: "crlf.txt", want: stats},
+		{fixture: "spacing.txt", want: stats},
+		{fixture: "trailing.txt", want: stats},
+		{fixture: "extra_field.txt", want: stats},
+		{fixture: "missing_value.txt", wantErr: ErrMissingField},
+		{fixture: "missing_field.txt", wantErr: ErrMissingField},
+		{fixture: "invalid_value.txt", wantErr: ErrInvalidValue},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.fixture, func(t *testing.T) {
+			t.Parallel()
+
+			input, err := os.ReadFile(filepath.Join("testdata", "stub_status", tt.fixture))
+			if err != nil {
+				t.Fatalf("failed to read fixture: %v", err)
+			}
+
+			got, err := ParseStubStats(bytes.NewReader(input))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ParseStubStats() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseStubStats() returned error: %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ParseStubStats() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetStubStatsResponseInfo(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Server", "nginx/1.25.3")
+		fmt.Fprint(w, validStabStats)
+	})
+
+	t.Run("http", func(t *testing.T) {
+		t.Parallel()
+
+		srv := httptest.NewServer(handler)
+		t.Cleanup(srv.Close)
+
+		stats, err := NewNginxClient(srv.Client(), srv.URL).GetStubStatsContext(context.Background())
+		if err != nil {
+			t.Fatalf("GetStubStatsContext() returned error: %v", err)
+		}
+		want := &ResponseInfo{Server: "nginx/1.25.3", RemoteAddr: srv.Listener.Addr().String()}
+		if !reflect.DeepEqual(stats.Response, want) {
+			t.Errorf("GetStubStatsContext() response = %+v, want %+v", stats.Response, want)
+		}
+	})
+
+	t.Run("https", func(t *testing.T) {
+		t.Parallel()
+
+		srv := httptest.NewUnstartedServer(handler)
+		srv.TLS = &tls.Config{MinVersion: tls.VersionTLS13}
+		srv.StartTLS()
+		t.Cleanup(srv.Close)
+
+		stats, err := NewNginxClient(srv.Client(), srv.URL).GetStubStatsContext(context.Background())
+		if err != nil {
+			t.Fatalf("GetStubStatsContext() returned error: %v", err)
+		}
+		if stats.Response.TLSVersion != "TLS 1.3" || stats.Response.TLSCipher == "" {
+			t.Errorf("GetStubStatsContext() response = %+v, want TLS 1.3 with a cipher", stats.Response)
+		}
+	})
+}
+
+func FuzzParseStubStats(f *testing.F) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "stub_status", "*.txt"))
+	if err != nil {
+		f.Fatalf("failed to list fixtures: %v", err)
+	}
+	for _, fixture := range fixtures {
+		input, err := os.ReadFile(fixture)
+		if err != nil {
+			f.Fatalf("failed to read fixture: %v", err)
+		}
+		f.Add(input)
+	}
+
+	f.Fuzz(func(t *testing.T, input []byte) {
+		stats, err := ParseStubStats(bytes.NewReader(input))
+		if err != nil {
+			if stats != nil {
+				t.Errorf("ParseStubStats() returned stats and error %v", err)
+			}
+			return
+		}
+		c := stats.Connections
+		for _, v := range []int64{c.Active, c.Accepted, c.Handled, c.Reading, c.Writing, c.Waiting, stats.Requests} {
+			if v < 0 {
+				t.Errorf("ParseStubStats() returned negative value in %+v", stats)
+			}
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidReqStatLine means a line of the reqstat output lacks a value or
+// has a value that is not a non-negative integer. It is wrapped with the
+// number of the line.
+var ErrInvalidReqStatLine = errors.New("invalid reqstat line")
+
+// reqStatColumns are the values following the key on every line of the
+// default req_status_show output.
+var reqStatColumns = []string{
+	"bytes_in", "bytes_out", "conn_total", "req_total",
+	"http_2xx", "http_3xx", "http_4xx", "http_5xx", "http_other_status",
+	"rt", "ups_req", "ups_rt", "ups_tries",
+	"http_200", "http_206", "http_302", "http_304", "http_403", "http_404", "http_416", "http_499",
+	"http_500", "http_502", "http_503", "http_504", "http_508", "http_other_detail_status",
+	"http_ups_4xx", "http_ups_5xx",
+}
+
+// ReqStatClient allows you to fetch the request statistics of the Tengine
+// ngx_http_reqstat_module from the req_status_show page.
+type ReqStatClient struct {
+	httpClient  *http.Client
+	apiEndpoint string
+}
+
+// ReqStat represents the request statistics of a reqstat key, usually a
+// virtual host.
+type ReqStat struct {
+	// Responses holds the responses by status code class: 2xx, 3xx, 4xx,
+	// 5xx and other.
+	Responses map[string]int64
+	// ResponseCodes holds the responses by status code for the codes
+	// reqstat reports individually, and the others as other.
+	ResponseCodes map[string]int64
+	// UpstreamResponses holds the upstream responses by status code class:
+	// 4xx and 5xx.
+	UpstreamResponses map[string]int64
+	Key               string
+	BytesIn           int64
+	BytesOut          int64
+	Connections       int64
+	Requests          int64
+	// RequestTime is the total time spent processing requests in
+	// milliseconds.
+	RequestTime      int64
+	UpstreamRequests int64
+	// UpstreamTime is the total time spent waiting for upstream responses
+	// in milliseconds.
+	UpstreamTime  int64
+	UpstreamTries int64
+}
+
+// NewReqStatClient creates a ReqStatClient.
+func NewReqStatClient(httpClient *http.Client, apiEndpoint string) *ReqStatClient {
+	client := &ReqStatClient{
+		apiEndpoint: apiEndpoint,
+		httpClient:  httpClient,
+	}
+
+	return client
+}
+
+// GetStats fetches the request statistics. The request is canceled when ctx
+// is done.
+func (client *ReqStatClient) GetStats(ctx context.Context) ([]ReqStat, error) {
+	body, err := getBody(ctx, client.httpClient, client.apiEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := ParseReqStats(bytes.NewReader(body))
+	if err != nil {
+		return nil, &Error{
+			Err:    fmt.Errorf("failed to parse response body %q: %w", string(body), err),
+			Reason: ReasonParse,
+		}
+	}
+
+	return stats, nil
+}
+
+// ParseReqStats parses the output of req_status_show with the default
+// req_status_show_field, one comma-separated line per key. As the key may
+// contain commas itself, the values are taken from the end of the line. Lines
+// of different zones with the same key are added up.
+func ParseReqStats(r io.Reader) ([]ReqStat, error) {
+	var stats []ReqStat
+	index := make(map[string]int)
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Split(line, ",")
+		if len(fields) <= len(reqStatColumns) {
+			return nil, fmt.Errorf("%w %d: expected a key and %d values, got %d fields", ErrInvalidReqStatLine, n, len(reqStatColumns), len(fields))
+		}
+		keyFields := len(fields) - len(reqStatColumns)
+
+		values := make(map[string]int64, len(reqStatColumns))
+		for i, name := range reqStatColumns {
+			value := strings.TrimSpace(fields[keyFields+i])
+			v, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || v < 0 {
+				return nil, fmt.Errorf("%w %d: %s %q", ErrInvalidReqStatLine, n, name, value)
+			}
+			values[name] = v
+		}
+
+		key := strings.Join(fields[:keyFields], ",")
+		i, ok := index[key]
+		if !ok {
+			i = len(stats)
+			index[key] = i
+			stats = append(stats, ReqStat{
+				Key:               key,
+				Responses:         make(map[string]int64),
+				ResponseCodes:     make(map[string]int64),
+				UpstreamResponses: make(map[string]int64),
+			})
+		}
+		stats[i].add(values)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read reqstat: %w", err)
+	}
+
+	return stats, nil
+}
+
+// add adds the values of a line of the reqstat output to s.
+func (s *ReqStat) add(values map[string]int64) {
+	s.BytesIn += values["bytes_in"]
+	s.BytesOut += values["bytes_out"]
+	s.Connections += values["conn_total"]
+	s.Requests += values["req_total"]
+	s.RequestTime += values["rt"]
+	s.UpstreamRequests += values["ups_req"]
+	s.UpstreamTime += values["ups_rt"]
+	s.UpstreamTries += values["ups_tries"]
+
+	for _, class := range []string{"2xx", "3xx", "4xx", "5xx"} {
+		s.Responses[class] += values["http_"+class]
+	}
+	s.Responses["other"] += values["http_other_status"]
+
+	for _, code := range []string{"200", "206", "302", "304", "403", "404", "416", "499", "500", "502", "503", "504", "508"} {
+		s.ResponseCodes[code] += values["http_"+code]
+	}
+	s.ResponseCodes["other"] += values["http_other_detail_status"]
+
+	s.UpstreamResponses["4xx"] += values["http_ups_4xx"]
+	s.UpstreamResponses["5xx"] += values["http_ups_5xx"]
+}
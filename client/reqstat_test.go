@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseReqStats(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []ReqStat
+		wantErr error
+	}{
+		{
+			name:  "default fields",
+			input: "example.com,162,6242,1,2,1,0,0,0,1,10,1,8,1,1,0,0,0,0,0,0,0,0,0,0,0,0,1,0,1\n",
+			want: []ReqStat{
+				{
+					Key:               "example.com",
+					BytesIn:           162,
+					BytesOut:          6242,
+					Connections:       1,
+					Requests:          2,
+					Responses:         map[string]int64{"2xx": 1, "3xx": 0, "4xx": 0, "5xx": 0, "other": 1},
+					ResponseCodes:     map[string]int64{"200": 1, "206": 0, "302": 0, "304": 0, "403": 0, "404": 0, "416": 0, "499": 0, "500": 0, "502": 0, "503": 0, "504": 0, "508": 0, "other": 1},
+					UpstreamResponses: map[string]int64{"4xx": 0, "5xx": 1},
+					RequestTime:       10,
+					UpstreamRequests:  1,
+					UpstreamTime:      8,
+					UpstreamTries:     1,
+				},
+			},
+		},
+		{
+			name:  "key with commas and lines of several zones",
+			input: "a.com,10.0.0.1:80,1,1,1,1,1,0,0,0,0,5,0,0,0,1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0\r\n\r\na.com,10.0.0.1:80,2,2,1,1,0,0,1,0,0,7,0,0,0,0,0,0,0,0,1,0,0,0,0,0,0,0,0,0,0\r\n",
+			want: []ReqStat{
+				{
+					Key:               "a.com,10.0.0.1:80",
+					BytesIn:           3,
+					BytesOut:          3,
+					Connections:       2,
+					Requests:          2,
+					Responses:         map[string]int64{"2xx": 1, "3xx": 0, "4xx": 1, "5xx": 0, "other": 0},
+					ResponseCodes:     map[string]int64{"200": 1, "206": 0, "302": 0, "304": 0, "403": 0, "404": 1, "416": 0, "499": 0, "500": 0, "502": 0, "503": 0, "504": 0, "508": 0, "other": 0},
+					UpstreamResponses: map[string]int64{"4xx": 0, "5xx": 0},
+					RequestTime:       12,
+				},
+			},
+		},
+		{
+			name:  "empty",
+			input: "\n",
+		},
+		{
+			name:    "missing values",
+			input:   "example.com,162,6242,1,1,1,0,0,0,0,10,1,10,1\n",
+			wantErr: ErrInvalidReqStatLine,
+		},
+		{
+			name:    "invalid value",
+			input:   "example.com,162,6242,1,2,1,0,0,0,1,10,1,8,1,1,0,0,0,0,0,0,0,0,0,0,0,0,1,0,-1\n",
+			wantErr: ErrInvalidReqStatLine,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseReqStats(strings.NewReader(tt.input))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseReqStats() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseReqStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReqStatClientGetStats(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.FileServer(http.Dir("testdata/reqstat")))
+	defer srv.Close()
+
+	stats, err := NewReqStatClient(srv.Client(), srv.URL+"/reqstat.txt").GetStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetStats() returned error: %v", err)
+	}
+	if len(stats) != 3 {
+		t.Fatalf("GetStats() returned %d keys, want 3", len(stats))
+	}
+	if got := stats[1]; got.Key != "api.example.com,10.0.0.10:443" || got.Requests != 310 || got.UpstreamResponses["5xx"] != 3 {
+		t.Errorf("GetStats()[1] = %+v, want 310 requests and 3 upstream 5xx responses for api.example.com,10.0.0.10:443", got)
+	}
+
+	_, err = NewReqStatClient(srv.Client(), srv.URL+"/missing.txt").GetStats(context.Background())
+	if got := ErrorReason(err); got != ReasonHTTPStatus {
+		t.Errorf("ErrorReason() = %q for a missing page, want %q", got, ReasonHTTPStatus)
+	}
+}
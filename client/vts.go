@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// VTSClient allows you to fetch the JSON status of the
+// nginx-module-vts and nginx-module-sts modules.
+type VTSClient struct {
+	httpClient  *http.Client
+	apiEndpoint string
+}
+
+// VTSStats represents the JSON status of nginx-module-vts, typically served
+// at /status/format/json. The JSON status of nginx-module-sts uses the same
+// format for the stream zones.
+type VTSStats struct {
+	ServerZones         map[string]VTSServerZone                  `json:"serverZones"`
+	FilterZones         map[string]map[string]VTSServerZone       `json:"filterZones"`
+	UpstreamZones       map[string][]VTSUpstreamServer            `json:"upstreamZones"`
+	CacheZones          map[string]VTSCacheZone                   `json:"cacheZones"`
+	StreamServerZones   map[string]VTSStreamServerZone            `json:"streamServerZones"`
+	StreamFilterZones   map[string]map[string]VTSStreamServerZone `json:"streamFilterZones"`
+	StreamUpstreamZones map[string][]VTSStreamUpstreamServer      `json:"streamUpstreamZones"`
+	HostName            string                                    `json:"hostName"`
+	NginxVersion        string                                    `json:"nginxVersion"`
+	Connections         VTSConnections                            `json:"connections"`
+}
+
+// VTSConnections represents client connections.
+type VTSConnections struct {
+	Active   int64 `json:"active"`
+	Reading  int64 `json:"reading"`
+	Writing  int64 `json:"writing"`
+	Waiting  int64 `json:"waiting"`
+	Accepted int64 `json:"accepted"`
+	Handled  int64 `json:"handled"`
+	Requests int64 `json:"requests"`
+}
+
+// VTSResponses represents the responses by status code class and, for http
+// zones, by cache status.
+type VTSResponses struct {
+	OneXX       int64 `json:"1xx"`
+	TwoXX       int64 `json:"2xx"`
+	ThreeXX     int64 `json:"3xx"`
+	FourXX      int64 `json:"4xx"`
+	FiveXX      int64 `json:"5xx"`
+	Miss        int64 `json:"miss"`
+	Bypass      int64 `json:"bypass"`
+	Expired     int64 `json:"expired"`
+	Stale       int64 `json:"stale"`
+	Updating    int64 `json:"updating"`
+	Revalidated int64 `json:"revalidated"`
+	Hit         int64 `json:"hit"`
+	Scarce      int64 `json:"scarce"`
+}
+
+// VTSServerZone represents an http server zone or filter zone.
+type VTSServerZone struct {
+	Responses      VTSResponses `json:"responses"`
+	RequestCounter int64        `json:"requestCounter"`
+	InBytes        int64        `json:"inBytes"`
+	OutBytes       int64        `json:"outBytes"`
+	RequestMsec    int64        `json:"requestMsec"`
+}
+
+// VTSUpstreamServer represents a server of an http upstream.
+type VTSUpstreamServer struct {
+	Server         string       `json:"server"`
+	Responses      VTSResponses `json:"responses"`
+	RequestCounter int64        `json:"requestCounter"`
+	InBytes        int64        `json:"inBytes"`
+	OutBytes       int64        `json:"outBytes"`
+	RequestMsec    int64        `json:"requestMsec"`
+	ResponseMsec   int64        `json:"responseMsec"`
+	Weight         int64        `json:"weight"`
+	MaxFails       int64        `json:"maxFails"`
+	FailTimeout    int64        `json:"failTimeout"`
+	Backup         bool         `json:"backup"`
+	Down           bool         `json:"down"`
+}
+
+// VTSCacheZone represents a cache zone.
+type VTSCacheZone struct {
+	Responses VTSResponses `json:"responses"`
+	MaxSize   int64        `json:"maxSize"`
+	UsedSize  int64        `json:"usedSize"`
+	InBytes   int64        `json:"inBytes"`
+	OutBytes  int64        `json:"outBytes"`
+}
+
+// VTSStreamServerZone represents a stream server zone or filter zone.
+type VTSStreamServerZone struct {
+	Protocol       string       `json:"protocol"`
+	Responses      VTSResponses `json:"responses"`
+	Port           int64        `json:"port"`
+	ConnectCounter int64        `json:"connectCounter"`
+	InBytes        int64        `json:"inBytes"`
+	OutBytes       int64        `json:"outBytes"`
+	SessionMsec    int64        `json:"sessionMsec"`
+}
+
+// VTSStreamUpstreamServer represents a server of a stream upstream.
+type VTSStreamUpstreamServer struct {
+	Server         string       `json:"server"`
+	Responses      VTSResponses `json:"responses"`
+	ConnectCounter int64        `json:"connectCounter"`
+	InBytes        int64        `json:"inBytes"`
+	OutBytes       int64        `json:"outBytes"`
+	SessionMsec    int64        `json:"sessionMsec"`
+	USessionMsec   int64        `json:"uSessionMsec"`
+	UConnectMsec   int64        `json:"uConnectMsec"`
+	UFirstByteMsec int64        `json:"uFirstByteMsec"`
+	Weight         int64        `json:"weight"`
+	MaxFails       int64        `json:"maxFails"`
+	FailTimeout    int64        `json:"failTimeout"`
+	Backup         bool         `json:"backup"`
+	Down           bool         `json:"down"`
+}
+
+// NewVTSClient creates a VTSClient.
+func NewVTSClient(httpClient *http.Client, apiEndpoint string) *VTSClient {
+	client := &VTSClient{
+		apiEndpoint: apiEndpoint,
+		httpClient:  httpClient,
+	}
+
+	return client
+}
+
+// GetStats fetches the JSON status. The request is canceled when ctx is done.
+func (client *VTSClient) GetStats(ctx context.Context) (*VTSStats, error) {
+	var stats VTSStats
+	if err := getJSON(ctx, client.httpClient, client.apiEndpoint, &stats); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVTSClientGetStats(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.FileServer(http.Dir("testdata/vts")))
+	defer srv.Close()
+
+	stats, err := NewVTSClient(srv.Client(), srv.URL+"/status.json").GetStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetStats() returned error: %v", err)
+	}
+
+	if stats.Connections.Requests != 340 {
+		t.Errorf("Connections.Requests = %d, want 340", stats.Connections.Requests)
+	}
+	if zone := stats.ServerZones["www.example.com"]; zone.RequestCounter != 300 || zone.Responses.FourXX != 7 {
+		t.Errorf("ServerZones[www.example.com] = %+v, want 300 requests and 7 4xx responses", zone)
+	}
+	if zone := stats.FilterZones["country::www.example.com"]["DE"]; zone.Responses.TwoXX != 38 {
+		t.Errorf("FilterZones[country::www.example.com][DE] = %+v, want 38 2xx responses", zone)
+	}
+	if servers := stats.UpstreamZones["backend"]; len(servers) != 2 || !servers[1].Down || servers[0].ResponseMsec != 8 {
+		t.Errorf("UpstreamZones[backend] = %+v, want an up server with 8ms responses and a down server", servers)
+	}
+	if cache := stats.CacheZones["static"]; cache.Responses.Scarce != 3 || cache.MaxSize != 1073741824 {
+		t.Errorf("CacheZones[static] = %+v, want 3 scarce responses and 1GiB max size", cache)
+	}
+
+	stats, err = NewVTSClient(srv.Client(), srv.URL+"/stream.json").GetStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetStats() returned error: %v", err)
+	}
+
+	if zone := stats.StreamServerZones["TCP:5432:127.0.0.1"]; zone.ConnectCounter != 25 || zone.Protocol != "TCP" {
+		t.Errorf("StreamServerZones[TCP:5432:127.0.0.1] = %+v, want 25 TCP connections", zone)
+	}
+	if zone := stats.StreamFilterZones["client::TCP:5432:127.0.0.1"]["10.1.0.4"]; zone.ConnectCounter != 5 {
+		t.Errorf("StreamFilterZones[client::TCP:5432:127.0.0.1][10.1.0.4] = %+v, want 5 connections", zone)
+	}
+	if servers := stats.StreamUpstreamZones["postgres"]; len(servers) != 1 || servers[0].UConnectMsec != 2 {
+		t.Errorf("StreamUpstreamZones[postgres] = %+v, want a server with 2ms connects", servers)
+	}
+}
@@ -0,0 +1,243 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/nginxinc/nginx-prometheus-exporter/accesslog"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// LineSource produces log lines, such as the lines appended to a file, until
+// ctx is done.
+type LineSource interface {
+	Run(ctx context.Context, handle func(line string))
+}
+
+// MessageSource is a LineSource receiving lines as messages, such as syslog
+// messages, which may be dropped or malformed.
+type MessageSource interface {
+	LineSource
+	Received() uint64
+	Dropped() uint64
+	Malformed() uint64
+}
+
+// HistogramOptions configures the histograms of the access log times.
+type HistogramOptions struct {
+	// Buckets are the classic buckets. Defaults to prometheus.DefBuckets,
+	// unless Native is set.
+	Buckets []float64
+	// Native enables native histograms.
+	Native bool
+}
+
+// upstreamTimes are the upstream time variables with a histogram.
+var upstreamTimes = []struct {
+	variable string
+	name     string
+	help     string
+}{
+	{variable: "upstream_connect_time", name: "upstream_connect_duration_seconds", help: "Time to connect to upstream servers, as written to the access log"},
+	{variable: "upstream_header_time", name: "upstream_header_duration_seconds", help: "Time to receive the response header from upstream servers, as written to the access log"},
+	{variable: "upstream_response_time", name: "upstream_response_duration_seconds", help: "Time to receive the response from upstream servers, as written to the access log"},
+}
+
+// AccessLogCollector counts the requests of NGINX access log lines read from
+// its sources. It implements prometheus.Collector interface.
+type AccessLogCollector struct {
+	parser        accesslog.Parser
+	logger        log.Logger
+	ctx           context.Context //nolint:containedctx // canceled by Stop
+	cancel        context.CancelFunc
+	requests      *prometheus.CounterVec
+	bytesSent     *prometheus.CounterVec
+	bytesReceived *prometheus.CounterVec
+	parseErrors   prometheus.Counter
+	requestTime   *prometheus.HistogramVec
+	upstreamTimes []*prometheus.HistogramVec
+	messageDescs  map[string]*prometheus.Desc
+	sources       []LineSource
+	hosts         []string
+	routes        []accesslog.Route
+	wg            sync.WaitGroup
+	once          sync.Once
+}
+
+// NewAccessLogCollector creates an AccessLogCollector which parses the lines
+// of the sources with parser once started. The host label of a request is the
+// first of hosts matching its host, and the route label is the name of the
+// first route matching its path.
+func NewAccessLogCollector(parser accesslog.Parser, sources []LineSource, hosts []string, routes []accesslog.Route, histogramOpts HistogramOptions, namespace string, constLabels map[string]string, logger log.Logger) *AccessLogCollector {
+	ctx, cancel := context.WithCancel(context.Background())
+	labelNames := []string{"status", "method", "host", "route"}
+	upstreamLabelNames := []string{"upstream", "upstream_addr"}
+	newHistogramVec := func(name, help string) *prometheus.HistogramVec {
+		opts := prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Subsystem:   "access_log",
+			Name:        name,
+			Help:        help,
+			ConstLabels: constLabels,
+			Buckets:     histogramOpts.Buckets,
+		}
+		if histogramOpts.Native {
+			opts.NativeHistogramBucketFactor = 1.1
+			opts.NativeHistogramMaxBucketNumber = 160
+			opts.NativeHistogramMinResetDuration = time.Hour
+		}
+		return prometheus.NewHistogramVec(opts, upstreamLabelNames)
+	}
+	upstreamTimeVecs := make([]*prometheus.HistogramVec, 0, len(upstreamTimes))
+	for _, t := range upstreamTimes {
+		upstreamTimeVecs = append(upstreamTimeVecs, newHistogramVec(t.name, t.help))
+	}
+	messageDescs := map[string]*prometheus.Desc{
+		"received":  prometheus.NewDesc(prometheus.BuildFQName(namespace, "access_log", "syslog_messages_total"), "Syslog messages received", nil, constLabels),
+		"dropped":   prometheus.NewDesc(prometheus.BuildFQName(namespace, "access_log", "syslog_dropped_messages_total"), "Syslog messages dropped because too many were waiting to be parsed", nil, constLabels),
+		"malformed": prometheus.NewDesc(prometheus.BuildFQName(namespace, "access_log", "syslog_malformed_messages_total"), "Syslog messages which are not RFC 3164 or RFC 5424 messages", nil, constLabels),
+	}
+	return &AccessLogCollector{
+		parser: parser,
+		logger: logger,
+		ctx:    ctx,
+		cancel: cancel,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "access_log",
+			Name:        "requests_total",
+			Help:        "Requests written to the access log",
+			ConstLabels: constLabels,
+		}, labelNames),
+		bytesSent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "access_log",
+			Name:        "response_bytes_total",
+			Help:        "Bytes sent to clients, as written to the access log",
+			ConstLabels: constLabels,
+		}, labelNames),
+		bytesReceived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "access_log",
+			Name:        "request_bytes_total",
+			Help:        "Bytes received from clients, as written to the access log",
+			ConstLabels: constLabels,
+		}, labelNames),
+		parseErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "access_log",
+			Name:        "parse_errors_total",
+			Help:        "Access log lines which do not match the log format",
+			ConstLabels: constLabels,
+		}),
+		requestTime:   newHistogramVec("request_duration_seconds", "Time to process requests, as written to the access log"),
+		upstreamTimes: upstreamTimeVecs,
+		messageDescs:  messageDescs,
+		sources:       sources,
+		hosts:         hosts,
+		routes:        routes,
+	}
+}
+
+// Start reads the sources in the background until Stop is called.
+func (c *AccessLogCollector) Start() {
+	c.once.Do(func() {
+		for _, source := range c.sources {
+			c.wg.Add(1)
+			go func(source LineSource) {
+				defer c.wg.Done()
+				source.Run(c.ctx, c.Observe)
+			}(source)
+		}
+	})
+}
+
+// Stop stops reading the sources and waits for them to return.
+func (c *AccessLogCollector) Stop() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+// Observe counts the request of an access log line.
+func (c *AccessLogCollector) Observe(line string) {
+	fields, err := c.parser.Parse(line)
+	if err != nil {
+		c.parseErrors.Inc()
+		level.Debug(c.logger).Log("msg", "Error parsing access log line", "line", line, "error", err.Error())
+		return
+	}
+
+	r := fields.Request()
+	labelValues := []string{r.Status, r.Method, accesslog.MatchHost(c.hosts, r.Host), accesslog.MatchRoute(c.routes, r.Path)}
+	c.requests.WithLabelValues(labelValues...).Inc()
+	c.bytesSent.WithLabelValues(labelValues...).Add(float64(r.BytesSent))
+	c.bytesReceived.WithLabelValues(labelValues...).Add(float64(r.BytesReceived))
+
+	upstream := fields.UpstreamName()
+	if seconds, ok := fields.Seconds("request_time"); ok {
+		c.requestTime.WithLabelValues(upstream, fields.UpstreamAddr()).Observe(seconds)
+	}
+	for i, t := range upstreamTimes {
+		for _, ut := range fields.UpstreamTimes(t.variable) {
+			c.upstreamTimes[i].WithLabelValues(upstream, ut.Addr).Observe(ut.Seconds)
+		}
+	}
+}
+
+// Describe sends the descriptors of the access log metrics to the provided
+// channel.
+func (c *AccessLogCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.bytesSent.Describe(ch)
+	c.bytesReceived.Describe(ch)
+	c.parseErrors.Describe(ch)
+	c.requestTime.Describe(ch)
+	for _, h := range c.upstreamTimes {
+		h.Describe(ch)
+	}
+	if c.hasMessageSources() {
+		for _, desc := range c.messageDescs {
+			ch <- desc
+		}
+	}
+}
+
+// Collect sends the access log metrics to the provided channel.
+func (c *AccessLogCollector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.bytesSent.Collect(ch)
+	c.bytesReceived.Collect(ch)
+	c.parseErrors.Collect(ch)
+	c.requestTime.Collect(ch)
+	for _, h := range c.upstreamTimes {
+		h.Collect(ch)
+	}
+
+	if !c.hasMessageSources() {
+		return
+	}
+	var received, dropped, malformed uint64
+	for _, source := range c.sources {
+		if s, ok := source.(MessageSource); ok {
+			received += s.Received()
+			dropped += s.Dropped()
+			malformed += s.Malformed()
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(c.messageDescs["received"], prometheus.CounterValue, float64(received))
+	ch <- prometheus.MustNewConstMetric(c.messageDescs["dropped"], prometheus.CounterValue, float64(dropped))
+	ch <- prometheus.MustNewConstMetric(c.messageDescs["malformed"], prometheus.CounterValue, float64(malformed))
+}
+
+// hasMessageSources reports whether any source receives messages.
+func (c *AccessLogCollector) hasMessageSources() bool {
+	for _, source := range c.sources {
+		if _, ok := source.(MessageSource); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+package collector
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/nginxinc/nginx-prometheus-exporter/accesslog"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// lineSource sends its lines once and then waits for ctx to be done.
+type lineSource []string
+
+func (s lineSource) Run(ctx context.Context, handle func(line string)) {
+	for _, line := range s {
+		handle(line)
+	}
+	<-ctx.Done()
+}
+
+// messageSource is a lineSource with fixed message counts.
+type messageSource struct {
+	lineSource
+	received, dropped, malformed uint64
+}
+
+func (s messageSource) Received() uint64  { return s.received }
+func (s messageSource) Dropped() uint64   { return s.dropped }
+func (s messageSource) Malformed() uint64 { return s.malformed }
+
+func TestAccessLogCollector(t *testing.T) {
+	t.Parallel()
+
+	parser, err := accesslog.NewParser(`$host "$request" $status $bytes_sent $request_length`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes := []accesslog.Route{{Name: "api", Regexp: regexp.MustCompile(`^/api/`)}}
+	c := NewAccessLogCollector(parser, nil, []string{"example.com"}, routes, HistogramOptions{}, "nginx", map[string]string{"tier": "edge"}, log.NewNopLogger())
+	for _, line := range []string{
+		`example.com "GET /api/users?id=1 HTTP/1.1" 200 612 310`,
+		`example.com "GET /api/items HTTP/1.1" 200 1000 290`,
+		`example.com "GET /index.html HTTP/1.1" 404 150 200`,
+		`attacker.test "GET /index.html HTTP/1.1" 404 150 200`,
+		`not an access log line`,
+	} {
+		c.Observe(line)
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+
+	api := map[string]string{"status": "200", "method": "GET", "host": "example.com", "route": "api", "tier": "edge"}
+	other := map[string]string{"status": "404", "method": "GET", "host": "example.com", "route": "other"}
+	unknownHost := map[string]string{"status": "404", "method": "GET", "host": "other", "route": "other"}
+	tests := []struct {
+		labels map[string]string
+		name   string
+		want   float64
+	}{
+		{name: "nginx_access_log_requests_total", labels: api, want: 2},
+		{name: "nginx_access_log_response_bytes_total", labels: api, want: 1612},
+		{name: "nginx_access_log_request_bytes_total", labels: api, want: 600},
+		{name: "nginx_access_log_requests_total", labels: other, want: 1},
+		{name: "nginx_access_log_requests_total", labels: unknownHost, want: 1},
+		{name: "nginx_access_log_parse_errors_total", want: 1},
+	}
+	for _, tt := range tests {
+		got, ok := metricValue(families, tt.name, tt.labels)
+		if !ok {
+			t.Errorf("%s%v is missing", tt.name, tt.labels)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s%v = %v, want %v", tt.name, tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestAccessLogCollectorStart(t *testing.T) {
+	t.Parallel()
+
+	parser, err := accesslog.NewParser(accesslog.FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewAccessLogCollector(parser, []LineSource{lineSource{`{"request":"GET / HTTP/1.1","status":"200"}`}}, nil, nil, HistogramOptions{}, "nginx", nil, log.NewNopLogger())
+	c.Start()
+	defer c.Stop()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	for i := 0; i < 100; i++ {
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("Gather() returned error: %v", err)
+		}
+		if got, ok := metricValue(families, "nginx_access_log_requests_total", map[string]string{"route": ""}); ok && got == 1 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("the line of the source was not counted")
+}
+
+func TestAccessLogCollectorMessageSources(t *testing.T) {
+	t.Parallel()
+
+	parser, err := accesslog.NewParser(accesslog.FormatCombined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := []LineSource{
+		lineSource{},
+		messageSource{received: 10, dropped: 1, malformed: 2},
+		messageSource{received: 5, malformed: 1},
+	}
+	c := NewAccessLogCollector(parser, sources, nil, nil, HistogramOptions{}, "nginx", nil, log.NewNopLogger())
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want float64
+	}{
+		{name: "nginx_access_log_syslog_messages_total", want: 15},
+		{name: "nginx_access_log_syslog_dropped_messages_total", want: 1},
+		{name: "nginx_access_log_syslog_malformed_messages_total", want: 3},
+	}
+	for _, tt := range tests {
+		got, ok := metricValue(families, tt.name, nil)
+		if !ok {
+			t.Errorf("%s is missing", tt.name)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	c = NewAccessLogCollector(parser, []LineSource{lineSource{}}, nil, nil, HistogramOptions{}, "nginx", nil, log.NewNopLogger())
+	registry = prometheus.NewRegistry()
+	registry.MustRegister(c)
+	families, err = registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+	if _, ok := metricValue(families, "nginx_access_log_syslog_messages_total", nil); ok {
+		t.Errorf("nginx_access_log_syslog_messages_total is exported without syslog sources")
+	}
+}
+
+func TestAccessLogCollectorHistograms(t *testing.T) {
+	t.Parallel()
+
+	parser, err := accesslog.NewParser(accesslog.FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewAccessLogCollector(parser, nil, nil, nil, HistogramOptions{Buckets: []float64{0.1, 1}}, "nginx", nil, log.NewNopLogger())
+	for _, line := range []string{
+		`{"status":"200","request_time":"0.250","proxy_host":"backend","upstream_addr":"10.0.0.1:80, 10.0.0.2:80","upstream_connect_time":"-, 0.001","upstream_response_time":"0.050, 0.200"}`,
+		`{"status":"200","request_time":"0.002"}`,
+	} {
+		c.Observe(line)
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+
+	tests := []struct {
+		labels    map[string]string
+		name      string
+		wantCount uint64
+		wantSum   float64
+	}{
+		{name: "nginx_access_log_request_duration_seconds", labels: map[string]string{"upstream": "backend", "upstream_addr": "10.0.0.2:80"}, wantCount: 1, wantSum: 0.25},
+		{name: "nginx_access_log_request_duration_seconds", labels: map[string]string{"upstream": "", "upstream_addr": ""}, wantCount: 1, wantSum: 0.002},
+		{name: "nginx_access_log_upstream_connect_duration_seconds", labels: map[string]string{"upstream": "backend", "upstream_addr": "10.0.0.2:80"}, wantCount: 1, wantSum: 0.001},
+		{name: "nginx_access_log_upstream_response_duration_seconds", labels: map[string]string{"upstream": "backend", "upstream_addr": "10.0.0.1:80"}, wantCount: 1, wantSum: 0.05},
+		{name: "nginx_access_log_upstream_response_duration_seconds", labels: map[string]string{"upstream": "backend", "upstream_addr": "10.0.0.2:80"}, wantCount: 1, wantSum: 0.2},
+	}
+	for _, tt := range tests {
+		h := histogramValue(families, tt.name, tt.labels)
+		if h == nil {
+			t.Errorf("%s%v is missing", tt.name, tt.labels)
+			continue
+		}
+		if h.GetSampleCount() != tt.wantCount || h.GetSampleSum() != tt.wantSum {
+			t.Errorf("%s%v count = %d, sum = %v, want %d, %v", tt.name, tt.labels, h.GetSampleCount(), h.GetSampleSum(), tt.wantCount, tt.wantSum)
+		}
+	}
+	if h := histogramValue(families, "nginx_access_log_upstream_connect_duration_seconds", map[string]string{"upstream_addr": "10.0.0.1:80"}); h != nil {
+		t.Errorf("the connect time of an unreached server was observed")
+	}
+}
+
+// histogramValue returns the histogram of the metric with the given name and
+// label values, or nil.
+func histogramValue(families []*dto.MetricFamily, name string, labels map[string]string) *dto.Histogram {
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if v, ok := labels[l.GetName()]; ok && v != l.GetValue() {
+					continue metrics
+				}
+			}
+			return m.GetHistogram()
+		}
+	}
+	return nil
+}
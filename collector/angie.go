@@ -0,0 +1,377 @@
+package collector
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/nginxinc/nginx-prometheus-exporter/client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// angieUpstreamServerStates extends the states of NGINX Plus upstream servers
+// with those only Angie reports.
+var angieUpstreamServerStates = map[string]float64{
+	"up":          1.0,
+	"draining":    2.0,
+	"down":        3.0,
+	"unavailable": 4.0,
+	"checking":    5.0,
+	"unhealthy":   6.0,
+	"recovering":  7.0,
+	"busy":        8.0,
+}
+
+var responseClasses = []string{"1xx", "2xx", "3xx", "4xx", "5xx"}
+
+// AngieCollector collects Angie metrics from its JSON status API. The metrics
+// are named like those of NginxPlusCollector. It implements
+// prometheus.Collector interface.
+type AngieCollector struct {
+	upMetric              prometheus.Gauge
+	logger                log.Logger
+	angieClient           *client.AngieClient
+	totalMetrics          map[string]*prometheus.Desc
+	serverZoneMetrics     map[string]*prometheus.Desc
+	locationZoneMetrics   map[string]*prometheus.Desc
+	upstreamMetrics       map[string]*prometheus.Desc
+	upstreamServerMetrics map[string]*prometheus.Desc
+	cacheZoneMetrics      map[string]*prometheus.Desc
+	limitRequestMetrics   map[string]*prometheus.Desc
+	limitConnMetrics      map[string]*prometheus.Desc
+	resolverMetrics       map[string]*prometheus.Desc
+	slabMetrics           map[string]*prometheus.Desc
+	mutex                 sync.Mutex
+}
+
+// NewAngieCollector creates an AngieCollector.
+func NewAngieCollector(angieClient *client.AngieClient, namespace string, constLabels map[string]string, logger log.Logger) *AngieCollector {
+	return &AngieCollector{
+		angieClient: angieClient,
+		logger:      logger,
+		totalMetrics: map[string]*prometheus.Desc{
+			"connections_accepted": newGlobalMetric(namespace, "connections_accepted", "Accepted client connections", constLabels),
+			"connections_dropped":  newGlobalMetric(namespace, "connections_dropped", "Dropped client connections", constLabels),
+			"connections_active":   newGlobalMetric(namespace, "connections_active", "Active client connections", constLabels),
+			"connections_idle":     newGlobalMetric(namespace, "connections_idle", "Idle client connections", constLabels),
+		},
+		serverZoneMetrics: map[string]*prometheus.Desc{
+			"processing":            newServerZoneMetric(namespace, "processing", "Client requests that are currently being processed", nil, constLabels),
+			"requests":              newServerZoneMetric(namespace, "requests", "Total client requests", nil, constLabels),
+			"responses":             newServerZoneMetric(namespace, "responses", "Total responses sent to clients", []string{"code"}, constLabels),
+			"responses_codes":       newServerZoneMetric(namespace, "responses_codes", "Total responses sent to clients", []string{"code"}, constLabels),
+			"discarded":             newServerZoneMetric(namespace, "discarded", "Requests completed without sending a response", nil, constLabels),
+			"received":              newServerZoneMetric(namespace, "received", "Bytes received from clients", nil, constLabels),
+			"sent":                  newServerZoneMetric(namespace, "sent", "Bytes sent to clients", nil, constLabels),
+			"ssl_handshakes":        newServerZoneMetric(namespace, "ssl_handshakes", "Successful SSL handshakes", nil, constLabels),
+			"ssl_handshakes_failed": newServerZoneMetric(namespace, "ssl_handshakes_failed", "Failed SSL handshakes", nil, constLabels),
+			"ssl_session_reuses":    newServerZoneMetric(namespace, "ssl_session_reuses", "Session reuses during SSL handshake", nil, constLabels),
+		},
+		locationZoneMetrics: map[string]*prometheus.Desc{
+			"requests":        newLocationZoneMetric(namespace, "requests", "Total client requests", constLabels),
+			"responses":       newAngieLocationZoneMetric(namespace, "responses", "Total responses sent to clients", constLabels),
+			"responses_codes": newAngieLocationZoneMetric(namespace, "responses_codes", "Total responses sent to clients", constLabels),
+			"discarded":       newLocationZoneMetric(namespace, "discarded", "Requests completed without sending a response", constLabels),
+			"received":        newLocationZoneMetric(namespace, "received", "Bytes received from clients", constLabels),
+			"sent":            newLocationZoneMetric(namespace, "sent", "Bytes sent to clients", constLabels),
+		},
+		upstreamMetrics: map[string]*prometheus.Desc{
+			"keepalives": newUpstreamMetric(namespace, "keepalives", "Idle keepalive connections", constLabels),
+		},
+		upstreamServerMetrics: map[string]*prometheus.Desc{
+			"state":           newUpstreamServerMetric(namespace, "state", "Current state", nil, constLabels),
+			"active":          newUpstreamServerMetric(namespace, "active", "Active connections", nil, constLabels),
+			"limit":           newUpstreamServerMetric(namespace, "limit", "Limit for connections which corresponds to the max_conns parameter of the upstream server. Zero value means there is no limit", nil, constLabels),
+			"requests":        newUpstreamServerMetric(namespace, "requests", "Total client requests", nil, constLabels),
+			"responses":       newUpstreamServerMetric(namespace, "responses", "Total responses sent to clients", []string{"code"}, constLabels),
+			"responses_codes": newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", []string{"code"}, constLabels),
+			"sent":            newUpstreamServerMetric(namespace, "sent", "Bytes sent to this server", nil, constLabels),
+			"received":        newUpstreamServerMetric(namespace, "received", "Bytes received to this server", nil, constLabels),
+			"fails":           newUpstreamServerMetric(namespace, "fails", "Number of unsuccessful attempts to communicate with the server", nil, constLabels),
+			"unavail":         newUpstreamServerMetric(namespace, "unavail", "How many times the server became unavailable for client requests (state 'unavailable') due to the number of unsuccessful attempts reaching the max_fails threshold", nil, constLabels),
+			"downtime":        newUpstreamServerMetric(namespace, "downtime", "Total time the server was unavailable in milliseconds", nil, constLabels),
+		},
+		cacheZoneMetrics: map[string]*prometheus.Desc{
+			"size":                      newCacheZoneMetric(namespace, "size", "Total size of the cache", constLabels),
+			"max_size":                  newCacheZoneMetric(namespace, "max_size", "Maximum size of the cache", constLabels),
+			"cold":                      newCacheZoneMetric(namespace, "cold", "Is the cache considered cold", constLabels),
+			"hit_responses":             newCacheZoneMetric(namespace, "hit_responses", "Total number of cache hits", constLabels),
+			"hit_bytes":                 newCacheZoneMetric(namespace, "hit_bytes", "Total number of bytes returned from cache", constLabels),
+			"stale_responses":           newCacheZoneMetric(namespace, "stale_responses", "Total number of stale cache hits", constLabels),
+			"stale_bytes":               newCacheZoneMetric(namespace, "stale_bytes", "Total number of bytes returned from stale cache", constLabels),
+			"updating_responses":        newCacheZoneMetric(namespace, "updating_responses", "Total number of cache hits while cache is updating", constLabels),
+			"updating_bytes":            newCacheZoneMetric(namespace, "updating_bytes", "Total number of bytes returned from cache while cache is updating", constLabels),
+			"revalidated_responses":     newCacheZoneMetric(namespace, "revalidated_responses", "Total number of cache revalidations", constLabels),
+			"revalidated_bytes":         newCacheZoneMetric(namespace, "revalidated_bytes", "Total number of bytes returned from cache revalidations", constLabels),
+			"miss_responses":            newCacheZoneMetric(namespace, "miss_responses", "Total number of cache misses", constLabels),
+			"miss_bytes":                newCacheZoneMetric(namespace, "miss_bytes", "Total number of bytes returned from cache misses", constLabels),
+			"miss_responses_written":    newCacheZoneMetric(namespace, "miss_responses_written", "Total number of cache misses written to cache", constLabels),
+			"miss_bytes_written":        newCacheZoneMetric(namespace, "miss_bytes_written", "Total number of bytes written to cache from cache misses", constLabels),
+			"expired_responses":         newCacheZoneMetric(namespace, "expired_responses", "Total number of cache hits with expired TTL", constLabels),
+			"expired_bytes":             newCacheZoneMetric(namespace, "expired_bytes", "Total number of bytes returned from cache hits with expired TTL", constLabels),
+			"expired_responses_written": newCacheZoneMetric(namespace, "expired_responses_written", "Total number of cache hits with expired TTL written to cache", constLabels),
+			"expired_bytes_written":     newCacheZoneMetric(namespace, "expired_bytes_written", "Total number of bytes written to cache from cache hits with expired TTL", constLabels),
+			"bypass_responses":          newCacheZoneMetric(namespace, "bypass_responses", "Total number of cache bypasses", constLabels),
+			"bypass_bytes":              newCacheZoneMetric(namespace, "bypass_bytes", "Total number of bytes returned from cache bypasses", constLabels),
+			"bypass_responses_written":  newCacheZoneMetric(namespace, "bypass_responses_written", "Total number of cache bypasses written to cache", constLabels),
+			"bypass_bytes_written":      newCacheZoneMetric(namespace, "bypass_bytes_written", "Total number of bytes written to cache from cache bypasses", constLabels),
+		},
+		limitRequestMetrics: map[string]*prometheus.Desc{
+			"passed":    newLimitRequestMetric(namespace, "passed", "Total number of requests that were neither limited nor accounted as limited", constLabels),
+			"skipped":   newLimitRequestMetric(namespace, "skipped", "Total number of requests that were not checked because the key was empty", constLabels),
+			"delayed":   newLimitRequestMetric(namespace, "delayed", "Total number of requests that were delayed", constLabels),
+			"rejected":  newLimitRequestMetric(namespace, "rejected", "Total number of requests that were rejected", constLabels),
+			"exhausted": newLimitRequestMetric(namespace, "exhausted", "Total number of requests that were rejected because the zone was full", constLabels),
+		},
+		limitConnMetrics: map[string]*prometheus.Desc{
+			"passed":    newLimitConnectionMetric(namespace, "passed", "Total number of connections that were neither limited nor accounted as limited", constLabels),
+			"skipped":   newLimitConnectionMetric(namespace, "skipped", "Total number of connections that were not checked because the key was empty", constLabels),
+			"rejected":  newLimitConnectionMetric(namespace, "rejected", "Total number of connections that were rejected", constLabels),
+			"exhausted": newLimitConnectionMetric(namespace, "exhausted", "Total number of connections that were rejected because the zone was full", constLabels),
+		},
+		resolverMetrics: map[string]*prometheus.Desc{
+			"name":     newResolverMetric(namespace, "name", "Total requests to resolve names to addresses", constLabels),
+			"srv":      newResolverMetric(namespace, "srv", "Total requests to resolve SRV records", constLabels),
+			"addr":     newResolverMetric(namespace, "addr", "Total requests to resolve addresses to names", constLabels),
+			"noerror":  newResolverMetric(namespace, "noerror", "Total number of successful responses", constLabels),
+			"formerr":  newResolverMetric(namespace, "formerr", "Total number of FORMERR responses", constLabels),
+			"servfail": newResolverMetric(namespace, "servfail", "Total number of SERVFAIL responses", constLabels),
+			"nxdomain": newResolverMetric(namespace, "nxdomain", "Total number of NXDOMAIN responses", constLabels),
+			"notimp":   newResolverMetric(namespace, "notimp", "Total number of NOTIMP responses", constLabels),
+			"refused":  newResolverMetric(namespace, "refused", "Total number of REFUSED responses", constLabels),
+			"timedout": newResolverMetric(namespace, "timedout", "Total number of timed out requests", constLabels),
+			"unknown":  newResolverMetric(namespace, "unknown", "Total requests completed with an unknown error", constLabels),
+		},
+		slabMetrics: map[string]*prometheus.Desc{
+			"pages_used": newSlabMetric(namespace, "pages_used", "Memory pages used in the shared memory zone", nil, constLabels),
+			"pages_free": newSlabMetric(namespace, "pages_free", "Memory pages free in the shared memory zone", nil, constLabels),
+			"slot_used":  newSlabMetric(namespace, "slot_used", "Memory slots of the given size used", []string{"slot"}, constLabels),
+			"slot_free":  newSlabMetric(namespace, "slot_free", "Memory slots of the given size free", []string{"slot"}, constLabels),
+			"slot_reqs":  newSlabMetric(namespace, "slot_reqs", "Total attempts to allocate memory slots of the given size", []string{"slot"}, constLabels),
+			"slot_fails": newSlabMetric(namespace, "slot_fails", "Failed attempts to allocate memory slots of the given size", []string{"slot"}, constLabels),
+		},
+		upMetric: newUpMetric(namespace, constLabels),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of Angie metrics
+// to the provided channel.
+func (c *AngieCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.upMetric.Desc()
+
+	for _, metrics := range []map[string]*prometheus.Desc{
+		c.totalMetrics, c.serverZoneMetrics, c.locationZoneMetrics, c.upstreamMetrics, c.upstreamServerMetrics,
+		c.cacheZoneMetrics, c.limitRequestMetrics, c.limitConnMetrics, c.resolverMetrics, c.slabMetrics,
+	} {
+		for _, m := range metrics {
+			ch <- m
+		}
+	}
+}
+
+// Collect fetches metrics from Angie and sends them to the provided channel.
+func (c *AngieCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+// CollectContext is like Collect but cancels the request to Angie when ctx is done.
+func (c *AngieCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	_ = c.collect(ctx, ch)
+}
+
+func (c *AngieCollector) collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	c.mutex.Lock() // To protect metrics from concurrent collects
+	defer c.mutex.Unlock()
+
+	stats, err := c.angieClient.GetStats(ctx)
+	if err != nil {
+		c.upMetric.Set(nginxDown)
+		ch <- c.upMetric
+		level.Warn(c.logger).Log("msg", "Error getting stats", "error", err.Error())
+		return err
+	}
+
+	c.upMetric.Set(nginxUp)
+	ch <- c.upMetric
+
+	ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_accepted"],
+		prometheus.CounterValue, float64(stats.Connections.Accepted))
+	ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_dropped"],
+		prometheus.CounterValue, float64(stats.Connections.Dropped))
+	ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_active"],
+		prometheus.GaugeValue, float64(stats.Connections.Active))
+	ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_idle"],
+		prometheus.GaugeValue, float64(stats.Connections.Idle))
+
+	for name, zone := range stats.HTTP.ServerZones {
+		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["processing"],
+			prometheus.GaugeValue, float64(zone.Requests.Processing), name)
+		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["requests"],
+			prometheus.CounterValue, float64(zone.Requests.Total), name)
+		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["discarded"],
+			prometheus.CounterValue, float64(zone.Requests.Discarded), name)
+		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["received"],
+			prometheus.CounterValue, float64(zone.Data.Received), name)
+		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["sent"],
+			prometheus.CounterValue, float64(zone.Data.Sent), name)
+		collectResponses(ch, c.serverZoneMetrics, zone.Responses, name)
+		if zone.SSL != nil {
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["ssl_handshakes"],
+				prometheus.CounterValue, float64(zone.SSL.Handshaked), name)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["ssl_handshakes_failed"],
+				prometheus.CounterValue, float64(zone.SSL.Failed+zone.SSL.Timedout), name)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["ssl_session_reuses"],
+				prometheus.CounterValue, float64(zone.SSL.Reuses), name)
+		}
+	}
+
+	for name, zone := range stats.HTTP.LocationZones {
+		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["requests"],
+			prometheus.CounterValue, float64(zone.Requests.Total), name)
+		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["discarded"],
+			prometheus.CounterValue, float64(zone.Requests.Discarded), name)
+		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["received"],
+			prometheus.CounterValue, float64(zone.Data.Received), name)
+		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["sent"],
+			prometheus.CounterValue, float64(zone.Data.Sent), name)
+		collectResponses(ch, c.locationZoneMetrics, zone.Responses, name)
+	}
+
+	for name, upstream := range stats.HTTP.Upstreams {
+		ch <- prometheus.MustNewConstMetric(c.upstreamMetrics["keepalives"],
+			prometheus.GaugeValue, float64(upstream.Keepalive), name)
+
+		for address, peer := range upstream.Peers {
+			labelValues := []string{name, address}
+			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["state"],
+				prometheus.GaugeValue, angieUpstreamServerStates[peer.State], labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["active"],
+				prometheus.GaugeValue, float64(peer.Selected.Current), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["limit"],
+				prometheus.GaugeValue, float64(peer.MaxConns), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["requests"],
+				prometheus.CounterValue, float64(peer.Selected.Total), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["sent"],
+				prometheus.CounterValue, float64(peer.Data.Sent), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["received"],
+				prometheus.CounterValue, float64(peer.Data.Received), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["fails"],
+				prometheus.CounterValue, float64(peer.Health.Fails), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["unavail"],
+				prometheus.CounterValue, float64(peer.Health.Unavailable), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["downtime"],
+				prometheus.CounterValue, float64(peer.Health.Downtime), labelValues...)
+			collectResponses(ch, c.upstreamServerMetrics, peer.Responses, labelValues...)
+		}
+	}
+
+	for name, cache := range stats.HTTP.Caches {
+		var cold float64
+		if cache.Cold {
+			cold = 1.0
+		}
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["size"],
+			prometheus.GaugeValue, float64(cache.Size), name)
+		if cache.MaxSize != nil {
+			ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["max_size"],
+				prometheus.GaugeValue, float64(*cache.MaxSize), name)
+		}
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["cold"],
+			prometheus.GaugeValue, cold, name)
+		for status, s := range map[string]client.AngieCacheStats{
+			"hit":         cache.Hit,
+			"stale":       cache.Stale,
+			"updating":    cache.Updating,
+			"revalidated": cache.Revalidated,
+		} {
+			ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics[status+"_responses"],
+				prometheus.CounterValue, float64(s.Responses), name)
+			ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics[status+"_bytes"],
+				prometheus.CounterValue, float64(s.Bytes), name)
+		}
+		for status, s := range map[string]client.AngieCacheStats{
+			"miss":    cache.Miss,
+			"expired": cache.Expired,
+			"bypass":  cache.Bypass,
+		} {
+			ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics[status+"_responses"],
+				prometheus.CounterValue, float64(s.Responses), name)
+			ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics[status+"_bytes"],
+				prometheus.CounterValue, float64(s.Bytes), name)
+			ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics[status+"_responses_written"],
+				prometheus.CounterValue, float64(s.ResponsesWritten), name)
+			ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics[status+"_bytes_written"],
+				prometheus.CounterValue, float64(s.BytesWritten), name)
+		}
+	}
+
+	for name, zone := range stats.HTTP.LimitReqs {
+		ch <- prometheus.MustNewConstMetric(c.limitRequestMetrics["passed"], prometheus.CounterValue, float64(zone.Passed), name)
+		ch <- prometheus.MustNewConstMetric(c.limitRequestMetrics["skipped"], prometheus.CounterValue, float64(zone.Skipped), name)
+		ch <- prometheus.MustNewConstMetric(c.limitRequestMetrics["delayed"], prometheus.CounterValue, float64(zone.Delayed), name)
+		ch <- prometheus.MustNewConstMetric(c.limitRequestMetrics["rejected"], prometheus.CounterValue, float64(zone.Rejected), name)
+		ch <- prometheus.MustNewConstMetric(c.limitRequestMetrics["exhausted"], prometheus.CounterValue, float64(zone.Exhausted), name)
+	}
+
+	for name, zone := range stats.HTTP.LimitConns {
+		ch <- prometheus.MustNewConstMetric(c.limitConnMetrics["passed"], prometheus.CounterValue, float64(zone.Passed), name)
+		ch <- prometheus.MustNewConstMetric(c.limitConnMetrics["skipped"], prometheus.CounterValue, float64(zone.Skipped), name)
+		ch <- prometheus.MustNewConstMetric(c.limitConnMetrics["rejected"], prometheus.CounterValue, float64(zone.Rejected), name)
+		ch <- prometheus.MustNewConstMetric(c.limitConnMetrics["exhausted"], prometheus.CounterValue, float64(zone.Exhausted), name)
+	}
+
+	for name, resolver := range stats.Resolvers {
+		ch <- prometheus.MustNewConstMetric(c.resolverMetrics["name"], prometheus.CounterValue, float64(resolver.Queries.Name), name)
+		ch <- prometheus.MustNewConstMetric(c.resolverMetrics["srv"], prometheus.CounterValue, float64(resolver.Queries.Srv), name)
+		ch <- prometheus.MustNewConstMetric(c.resolverMetrics["addr"], prometheus.CounterValue, float64(resolver.Queries.Addr), name)
+		ch <- prometheus.MustNewConstMetric(c.resolverMetrics["noerror"], prometheus.CounterValue, float64(resolver.Responses.Success), name)
+		ch <- prometheus.MustNewConstMetric(c.resolverMetrics["formerr"], prometheus.CounterValue, float64(resolver.Responses.FormatError), name)
+		ch <- prometheus.MustNewConstMetric(c.resolverMetrics["servfail"], prometheus.CounterValue, float64(resolver.Responses.ServerFailure), name)
+		ch <- prometheus.MustNewConstMetric(c.resolverMetrics["nxdomain"], prometheus.CounterValue, float64(resolver.Responses.NotFound), name)
+		ch <- prometheus.MustNewConstMetric(c.resolverMetrics["notimp"], prometheus.CounterValue, float64(resolver.Responses.Unimplemented), name)
+		ch <- prometheus.MustNewConstMetric(c.resolverMetrics["refused"], prometheus.CounterValue, float64(resolver.Responses.Refused), name)
+		ch <- prometheus.MustNewConstMetric(c.resolverMetrics["timedout"], prometheus.CounterValue, float64(resolver.Responses.Timedout), name)
+		ch <- prometheus.MustNewConstMetric(c.resolverMetrics["unknown"], prometheus.CounterValue, float64(resolver.Responses.Other), name)
+	}
+
+	for name, slab := range stats.Slabs {
+		ch <- prometheus.MustNewConstMetric(c.slabMetrics["pages_used"], prometheus.GaugeValue, float64(slab.Pages.Used), name)
+		ch <- prometheus.MustNewConstMetric(c.slabMetrics["pages_free"], prometheus.GaugeValue, float64(slab.Pages.Free), name)
+		for size, slot := range slab.Slots {
+			ch <- prometheus.MustNewConstMetric(c.slabMetrics["slot_used"], prometheus.GaugeValue, float64(slot.Used), name, size)
+			ch <- prometheus.MustNewConstMetric(c.slabMetrics["slot_free"], prometheus.GaugeValue, float64(slot.Free), name, size)
+			ch <- prometheus.MustNewConstMetric(c.slabMetrics["slot_reqs"], prometheus.CounterValue, float64(slot.Reqs), name, size)
+			ch <- prometheus.MustNewConstMetric(c.slabMetrics["slot_fails"], prometheus.CounterValue, float64(slot.Fails), name, size)
+		}
+	}
+
+	return nil
+}
+
+// collectResponses sends the responses by status code class and by status
+// code. Angie reports the responses by status code only.
+func collectResponses(ch chan<- prometheus.Metric, metrics map[string]*prometheus.Desc, responses map[string]int64, labelValues ...string) {
+	classes := make(map[string]int64, len(responseClasses))
+	for code, count := range responses {
+		ch <- prometheus.MustNewConstMetric(metrics["responses_codes"],
+			prometheus.CounterValue, float64(count), append(labelValues, code)...)
+		if len(code) == 3 {
+			classes[code[:1]+"xx"] += count
+		}
+	}
+	for _, class := range responseClasses {
+		ch <- prometheus.MustNewConstMetric(metrics["responses"],
+			prometheus.CounterValue, float64(classes[class]), append(labelValues, class)...)
+	}
+}
+
+func newAngieLocationZoneMetric(namespace string, metricName string, docString string, constLabels prometheus.Labels) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "location_zone", metricName), docString, []string{"location_zone", "code"}, constLabels)
+}
+
+func newSlabMetric(namespace string, metricName string, docString string, variableLabelNames []string, constLabels prometheus.Labels) *prometheus.Desc {
+	labels := []string{"zone"}
+	labels = append(labels, variableLabelNames...)
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "slab", metricName), docString, labels, constLabels)
+}
@@ -0,0 +1,98 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/nginxinc/nginx-prometheus-exporter/client"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestAngieCollector(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join("..", "client", "testdata", "angie", "status.json"))
+	}))
+	defer srv.Close()
+
+	c := NewAngieCollector(client.NewAngieClient(srv.Client(), srv.URL), "angie", nil, log.NewNopLogger())
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   float64
+	}{
+		{name: "angie_up", want: 1},
+		{name: "angie_connections_accepted", want: 2257},
+		{name: "angie_server_zone_requests", labels: map[string]string{"server_zone": "www.example.com"}, want: 4327},
+		{name: "angie_server_zone_responses", labels: map[string]string{"server_zone": "www.example.com", "code": "3xx"}, want: 18},
+		{name: "angie_server_zone_responses", labels: map[string]string{"server_zone": "www.example.com", "code": "5xx"}, want: 0},
+		{name: "angie_server_zone_responses_codes", labels: map[string]string{"server_zone": "www.example.com", "code": "404"}, want: 4},
+		{name: "angie_server_zone_ssl_handshakes_failed", labels: map[string]string{"server_zone": "www.example.com"}, want: 3},
+		{name: "angie_location_zone_responses", labels: map[string]string{"location_zone": "media", "code": "2xx"}, want: 3},
+		{name: "angie_upstream_keepalives", labels: map[string]string{"upstream": "backend"}, want: 2},
+		{name: "angie_upstream_server_state", labels: map[string]string{"upstream": "backend", "server": "192.168.16.4:80"}, want: 1},
+		{name: "angie_upstream_server_requests", labels: map[string]string{"upstream": "backend", "server": "192.168.16.4:80"}, want: 232},
+		{name: "angie_upstream_server_responses", labels: map[string]string{"upstream": "backend", "server": "192.168.16.4:80", "code": "2xx"}, want: 222},
+		{name: "angie_cache_miss_bytes_written", labels: map[string]string{"zone": "cache"}, want: 512},
+		{name: "angie_limit_request_rejected", labels: map[string]string{"zone": "perserver"}, want: 26},
+		{name: "angie_limit_connection_rejected", labels: map[string]string{"zone": "perip"}, want: 2},
+		{name: "angie_resolver_nxdomain", labels: map[string]string{"resolver": "resolver_zone"}, want: 1},
+		{name: "angie_slab_slot_free", labels: map[string]string{"zone": "cache", "slot": "64"}, want: 63},
+	}
+	for _, tt := range tests {
+		got, ok := metricValue(families, tt.name, tt.labels)
+		if !ok {
+			t.Errorf("%s%v is missing", tt.name, tt.labels)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s%v = %v, want %v", tt.name, tt.labels, got, tt.want)
+		}
+	}
+	if _, ok := metricValue(families, "angie_cache_max_size", map[string]string{"zone": "cache"}); ok {
+		t.Errorf("angie_cache_max_size is reported for a cache without max_size")
+	}
+}
+
+// metricValue returns the value of the gauge or counter with the given name
+// and labels.
+func metricValue(families []*dto.MetricFamily, name string, labels map[string]string) (float64, bool) {
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range f.GetMetric() {
+			matched := 0
+			for _, l := range m.GetLabel() {
+				if v, ok := labels[l.GetName()]; ok {
+					if v != l.GetValue() {
+						continue metrics
+					}
+					matched++
+				}
+			}
+			if matched != len(labels) {
+				continue
+			}
+			if m.GetCounter() != nil {
+				return m.GetCounter().GetValue(), true
+			}
+			return m.GetGauge().GetValue(), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,132 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/nginxinc/nginx-prometheus-exporter/buildinfo"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// buildInfoTimeout limits the time the build information command may run.
+const buildInfoTimeout = 10 * time.Second
+
+// BuildInfoCollector collects the version and the modules of NGINX from the
+// output of nginx -V. It implements prometheus.Collector interface.
+//
+// The command runs on the first collect and again only when the file of its
+// executable changes, such as when NGINX is upgraded.
+type BuildInfoCollector struct {
+	logger  log.Logger
+	metrics map[string]*prometheus.Desc
+	info    *buildinfo.Info
+	command []string
+	// binary identifies the executable of the command the info was read
+	// from.
+	binary binaryState
+	mutex  sync.Mutex
+}
+
+type binaryState struct {
+	modTime time.Time
+	path    string
+	size    int64
+}
+
+// NewBuildInfoCollector creates a BuildInfoCollector which runs command, such
+// as []string{"nginx", "-V"}.
+func NewBuildInfoCollector(command []string, namespace string, constLabels map[string]string, logger log.Logger) (*BuildInfoCollector, error) {
+	if len(command) == 0 {
+		return nil, errors.New("empty build information command")
+	}
+	return &BuildInfoCollector{
+		logger:  logger,
+		command: command,
+		metrics: map[string]*prometheus.Desc{
+			"build_info":  prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "build_info"), "Version, compiler and TLS library of NGINX from nginx -V", []string{"product", "version", "compiler", "openssl"}, constLabels),
+			"module_info": prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "module_info"), "Modules NGINX was built with from nginx -V", []string{"module", "type", "source"}, constLabels),
+		},
+	}, nil
+}
+
+// Describe sends the descriptors of the build information metrics to the
+// provided channel.
+func (c *BuildInfoCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.metrics {
+		ch <- m
+	}
+}
+
+// Collect sends the build information of NGINX to the provided channel,
+// running the command when its executable changed.
+func (c *BuildInfoCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	binary, err := c.binaryState()
+	if err != nil {
+		level.Error(c.logger).Log("msg", "Error finding the build information command", "command", c.command[0], "error", err.Error())
+		c.info = nil
+		return
+	}
+	if c.info == nil || binary != c.binary {
+		info, err := c.run()
+		if err != nil {
+			level.Error(c.logger).Log("msg", "Error reading the build information of NGINX", "error", err.Error())
+			c.info = nil
+			return
+		}
+		c.info = &info
+		c.binary = binary
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.metrics["build_info"], prometheus.GaugeValue, 1,
+		c.info.Product, c.info.Version, c.info.Compiler, c.info.OpenSSL)
+	seen := make(map[buildinfo.Module]bool, len(c.info.Modules))
+	for _, m := range c.info.Modules {
+		if seen[m] {
+			continue
+		}
+		seen[m] = true
+		moduleType, source := "static", "builtin"
+		if m.Dynamic {
+			moduleType = "dynamic"
+		}
+		if m.ThirdParty {
+			source = "third_party"
+		}
+		ch <- prometheus.MustNewConstMetric(c.metrics["module_info"], prometheus.GaugeValue, 1, m.Name, moduleType, source)
+	}
+}
+
+func (c *BuildInfoCollector) binaryState() (binaryState, error) {
+	path, err := exec.LookPath(c.command[0])
+	if err != nil {
+		return binaryState{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return binaryState{}, err
+	}
+	return binaryState{path: path, modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// run runs the command and parses its output. nginx -V prints to the
+// standard error.
+func (c *BuildInfoCollector) run() (buildinfo.Info, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), buildInfoTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, c.command[0], c.command[1:]...).CombinedOutput()
+	if err != nil {
+		return buildinfo.Info{}, fmt.Errorf("%w: %q", err, output)
+	}
+	return buildinfo.Parse(string(output))
+}
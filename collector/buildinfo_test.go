@@ -0,0 +1,74 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestBuildInfoCollector(t *testing.T) {
+	t.Parallel()
+
+	command := filepath.Join(t.TempDir(), "nginx")
+	writeCommand := func(version string, mtime time.Time) {
+		script := "#!/bin/sh\ncat >&2 <<EOF\nnginx version: nginx/" + version + "\n" +
+			"built by gcc 12.2.0\nbuilt with OpenSSL 3.0.11 19 Sep 2023\n" +
+			"configure arguments: --with-http_ssl_module --with-stream=dynamic --add-module=/build/ngx_brotli\nEOF\n"
+		if err := os.WriteFile(command, []byte(script), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(command, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeCommand("1.25.3", time.Unix(1700000000, 0))
+
+	c, err := NewBuildInfoCollector([]string{command, "-V"}, "nginx", nil, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+	tests := []struct {
+		labels map[string]string
+		name   string
+	}{
+		{name: "nginx_build_info", labels: map[string]string{"product": "nginx", "version": "1.25.3", "compiler": "gcc 12.2.0", "openssl": "OpenSSL 3.0.11"}},
+		{name: "nginx_module_info", labels: map[string]string{"module": "http_ssl_module", "type": "static", "source": "builtin"}},
+		{name: "nginx_module_info", labels: map[string]string{"module": "stream", "type": "dynamic", "source": "builtin"}},
+		{name: "nginx_module_info", labels: map[string]string{"module": "ngx_brotli", "type": "static", "source": "third_party"}},
+	}
+	for _, tt := range tests {
+		if got, ok := metricValue(families, tt.name, tt.labels); !ok || got != 1 {
+			t.Errorf("%s%v = %v (reported %v), want 1", tt.name, tt.labels, got, ok)
+		}
+	}
+
+	// The output is cached until the binary changes.
+	writeCommand("1.25.4", time.Unix(1700000000, 0))
+	families, err = registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+	if _, ok := metricValue(families, "nginx_build_info", map[string]string{"version": "1.25.3"}); !ok {
+		t.Errorf("nginx_build_info was read again for an unchanged binary")
+	}
+
+	writeCommand("1.25.4", time.Unix(1700000100, 0))
+	families, err = registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+	if _, ok := metricValue(families, "nginx_build_info", map[string]string{"version": "1.25.4"}); !ok {
+		t.Errorf("nginx_build_info was not read again after the binary changed")
+	}
+}
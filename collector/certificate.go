@@ -0,0 +1,171 @@
+package collector
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/nginxinc/nginx-prometheus-exporter/nginxconf"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CertificateCollector collects the validity of the TLS certificates used by
+// NGINX. The certificate files are matched by globs or referenced by an NGINX
+// configuration file. It implements prometheus.Collector interface.
+type CertificateCollector struct {
+	logger     log.Logger
+	metrics    map[string]*prometheus.Desc
+	configPath string
+	globs      []string
+	mutex      sync.Mutex
+}
+
+// NewCertificateCollector creates a CertificateCollector which reads the
+// files matching globs and, when configPath is set, the files of the
+// ssl_certificate and proxy_ssl_certificate directives of the configuration
+// file at configPath on every collect.
+func NewCertificateCollector(configPath string, globs []string, namespace string, constLabels map[string]string, logger log.Logger) *CertificateCollector {
+	certificateLabels := []string{"file", "index"}
+	return &CertificateCollector{
+		logger:     logger,
+		configPath: configPath,
+		globs:      globs,
+		metrics: map[string]*prometheus.Desc{
+			"file_load_success":     newCertificateMetric(namespace, "file_load_success", "Whether the certificates of the file were loaded", []string{"file"}, constLabels),
+			"not_before":            newCertificateMetric(namespace, "not_before_timestamp_seconds", "Start of the validity of the certificate since unix epoch in seconds", certificateLabels, constLabels),
+			"not_after":             newCertificateMetric(namespace, "not_after_timestamp_seconds", "End of the validity of the certificate since unix epoch in seconds", certificateLabels, constLabels),
+			"info":                  newCertificateMetric(namespace, "info", "Subject, issuer, serial number, subject alternative names and key type of the certificate", append(certificateLabels, "subject", "issuer", "serial", "sans", "key_type"), constLabels),
+			"server_name_not_after": newCertificateMetric(namespace, "server_name_not_after_timestamp_seconds", "End of the validity of the certificate chain of the file used by the server name since unix epoch in seconds", []string{"context", "directive", "server_name", "file"}, constLabels),
+		},
+	}
+}
+
+func newCertificateMetric(namespace, metricName, docString string, variableLabelNames []string, constLabels prometheus.Labels) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "certificate", metricName), docString, variableLabelNames, constLabels)
+}
+
+// Describe sends the descriptors of the certificate metrics to the provided
+// channel.
+func (c *CertificateCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.metrics {
+		ch <- m
+	}
+}
+
+// Collect reads the certificate files and sends their metrics to the provided
+// channel.
+func (c *CertificateCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var files []string
+	for _, glob := range c.globs {
+		paths, err := filepath.Glob(glob)
+		if err != nil {
+			level.Error(c.logger).Log("msg", "Invalid certificate glob", "glob", glob, "error", err.Error())
+			continue
+		}
+		files = append(files, paths...)
+	}
+	var references []nginxconf.Certificate
+	if c.configPath != "" {
+		cfg, err := nginxConfigs.Load(c.configPath)
+		if err != nil {
+			level.Error(c.logger).Log("msg", "Error parsing the NGINX configuration", "error", err.Error())
+		} else {
+			references = nginxconf.Certificates(cfg)
+		}
+	}
+	for _, r := range references {
+		files = append(files, r.Path)
+	}
+	slices.Sort(files)
+	files = slices.Compact(files)
+
+	// The chain of a file is valid until its first certificate expires.
+	chainNotAfter := make(map[string]float64, len(files))
+	for _, file := range files {
+		certificates, err := loadCertificates(file)
+		if err != nil {
+			level.Error(c.logger).Log("msg", "Error loading certificates", "file", file, "error", err.Error())
+			ch <- prometheus.MustNewConstMetric(c.metrics["file_load_success"], prometheus.GaugeValue, 0, file)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.metrics["file_load_success"], prometheus.GaugeValue, 1, file)
+
+		for i, cert := range certificates {
+			index := strconv.Itoa(i)
+			notAfter := float64(cert.NotAfter.Unix())
+			ch <- prometheus.MustNewConstMetric(c.metrics["not_before"], prometheus.GaugeValue, float64(cert.NotBefore.Unix()), file, index)
+			ch <- prometheus.MustNewConstMetric(c.metrics["not_after"], prometheus.GaugeValue, notAfter, file, index)
+			ch <- prometheus.MustNewConstMetric(c.metrics["info"], prometheus.GaugeValue, 1,
+				file, index, cert.Subject.String(), cert.Issuer.String(), cert.SerialNumber.Text(16), strings.Join(subjectAltNames(cert), ","), cert.PublicKeyAlgorithm.String())
+			if previous, ok := chainNotAfter[file]; !ok || notAfter < previous {
+				chainNotAfter[file] = notAfter
+			}
+		}
+	}
+
+	for _, r := range references {
+		notAfter, ok := chainNotAfter[r.Path]
+		if !ok {
+			continue
+		}
+		for _, name := range r.ServerNames {
+			ch <- prometheus.MustNewConstMetric(c.metrics["server_name_not_after"], prometheus.GaugeValue, notAfter, r.Context, r.Directive, name, r.Path)
+		}
+	}
+}
+
+// loadCertificates returns the certificates of a PEM file, starting with the
+// certificate of the server as NGINX expects. Other blocks, such as the
+// private key NGINX allows in the same file, are skipped.
+func loadCertificates(file string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var certificates []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("certificate %d: %w", len(certificates), err)
+		}
+		certificates = append(certificates, cert)
+	}
+	if len(certificates) == 0 {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+	return certificates, nil
+}
+
+// subjectAltNames returns the DNS names, IP addresses, email addresses and
+// URIs of the certificate.
+func subjectAltNames(cert *x509.Certificate) []string {
+	names := slices.Clone(cert.DNSNames)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	names = append(names, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	return names
+}
@@ -0,0 +1,173 @@
+package collector
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCertificateCollector(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Unix(1700000000, 0),
+		NotAfter:              time.Unix(1800000000, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	ca := createCertificate(t, caTemplate, caTemplate, caKey.Public(), caKey)
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaLeaf := createCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(0x2a),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com", "www.example.com"},
+		NotBefore:    time.Unix(1710000000, 0),
+		NotAfter:     time.Unix(1900000000, 0),
+	}, caTemplate, ecdsaKey.Public(), caKey)
+	ed25519Public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed25519Leaf := createCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com"},
+		NotBefore:    time.Unix(1710000000, 0),
+		NotAfter:     time.Unix(1750000000, 0),
+	}, caTemplate, ed25519Public, caKey)
+
+	writePEM(t, filepath.Join(dir, "ecdsa.pem"), ecdsaLeaf, ca)
+	writePEM(t, filepath.Join(dir, "ed25519.pem"), ed25519Leaf)
+	writePEM(t, filepath.Join(dir, "extra", "ca.pem"), ca)
+	if err := os.WriteFile(filepath.Join(dir, "invalid.pem"), []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	conf := `
+http {
+    server {
+        server_name example.com www.example.com;
+        ssl_certificate ecdsa.pem;
+        ssl_certificate ed25519.pem;
+    }
+    server {
+        server_name broken.example.com;
+        ssl_certificate invalid.pem;
+    }
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "nginx.conf"), []byte(conf), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCertificateCollector(filepath.Join(dir, "nginx.conf"), []string{filepath.Join(dir, "extra", "*.pem")}, "nginx", nil, log.NewNopLogger())
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+
+	ecdsaFile := filepath.Join(dir, "ecdsa.pem")
+	ed25519File := filepath.Join(dir, "ed25519.pem")
+	tests := []struct {
+		labels map[string]string
+		name   string
+		want   float64
+	}{
+		{name: "nginx_certificate_file_load_success", labels: map[string]string{"file": ecdsaFile}, want: 1},
+		{name: "nginx_certificate_file_load_success", labels: map[string]string{"file": filepath.Join(dir, "extra", "ca.pem")}, want: 1},
+		{name: "nginx_certificate_file_load_success", labels: map[string]string{"file": filepath.Join(dir, "invalid.pem")}, want: 0},
+		{name: "nginx_certificate_not_before_timestamp_seconds", labels: map[string]string{"file": ecdsaFile, "index": "0"}, want: 1710000000},
+		{name: "nginx_certificate_not_after_timestamp_seconds", labels: map[string]string{"file": ecdsaFile, "index": "0"}, want: 1900000000},
+		{name: "nginx_certificate_not_after_timestamp_seconds", labels: map[string]string{"file": ecdsaFile, "index": "1"}, want: 1800000000},
+		{
+			name: "nginx_certificate_info",
+			labels: map[string]string{
+				"file": ecdsaFile, "index": "0", "subject": "CN=example.com", "issuer": "CN=Test CA",
+				"serial": "2a", "sans": "example.com,www.example.com", "key_type": "ECDSA",
+			},
+			want: 1,
+		},
+		{
+			name: "nginx_certificate_info",
+			labels: map[string]string{
+				"file": ed25519File, "index": "0", "subject": "CN=example.com", "issuer": "CN=Test CA",
+				"serial": "3", "sans": "example.com", "key_type": "Ed25519",
+			},
+			want: 1,
+		},
+		{
+			name:   "nginx_certificate_server_name_not_after_timestamp_seconds",
+			labels: map[string]string{"context": "http", "directive": "ssl_certificate", "server_name": "www.example.com", "file": ecdsaFile},
+			want:   1800000000,
+		},
+		{
+			name:   "nginx_certificate_server_name_not_after_timestamp_seconds",
+			labels: map[string]string{"context": "http", "directive": "ssl_certificate", "server_name": "example.com", "file": ed25519File},
+			want:   1750000000,
+		},
+	}
+	for _, tt := range tests {
+		got, ok := metricValue(families, tt.name, tt.labels)
+		if !ok {
+			t.Errorf("%s%v is missing", tt.name, tt.labels)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s%v = %v, want %v", tt.name, tt.labels, got, tt.want)
+		}
+	}
+	if _, ok := metricValue(families, "nginx_certificate_server_name_not_after_timestamp_seconds", map[string]string{"server_name": "broken.example.com"}); ok {
+		t.Errorf("nginx_certificate_server_name_not_after_timestamp_seconds reported for an invalid file")
+	}
+}
+
+func createCertificate(t *testing.T, template, parent *x509.Certificate, public crypto.PublicKey, signer crypto.Signer) []byte {
+	t.Helper()
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, public, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func writePEM(t *testing.T, path string, certificates ...[]byte) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	var data []byte
+	for _, der := range certificates {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
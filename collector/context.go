@@ -0,0 +1,43 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ContextCollector is a prometheus.Collector whose scrapes can be bounded by a
+// context, for example to give up before Prometheus abandons the scrape.
+type ContextCollector interface {
+	prometheus.Collector
+	CollectContext(ctx context.Context, ch chan<- prometheus.Metric)
+}
+
+// CollectContext collects c with ctx if c is a ContextCollector. Otherwise ctx
+// is ignored.
+func CollectContext(ctx context.Context, c prometheus.Collector, ch chan<- prometheus.Metric) {
+	if cc, ok := c.(ContextCollector); ok {
+		cc.CollectContext(ctx, ch)
+		return
+	}
+	c.Collect(ch)
+}
+
+// WithContext returns a prometheus.Collector which collects c with ctx. It is
+// meant for a registry that lives for a single scrape.
+func WithContext(ctx context.Context, c prometheus.Collector) prometheus.Collector {
+	return &contextCollector{ctx: ctx, collector: c}
+}
+
+type contextCollector struct {
+	ctx       context.Context //nolint:containedctx // the collector lives for a single scrape
+	collector prometheus.Collector
+}
+
+func (c *contextCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.collector.Describe(ch)
+}
+
+func (c *contextCollector) Collect(ch chan<- prometheus.Metric) {
+	CollectContext(c.ctx, c.collector, ch)
+}
@@ -0,0 +1,115 @@
+package collector
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/nginxinc/nginx-prometheus-exporter/errorlog"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrorLogCollector counts the entries of NGINX error log lines read from its
+// sources. It implements prometheus.Collector interface.
+type ErrorLogCollector struct {
+	logger      log.Logger
+	ctx         context.Context //nolint:containedctx // canceled by Stop
+	cancel      context.CancelFunc
+	entries     *prometheus.CounterVec
+	events      *prometheus.CounterVec
+	parseErrors prometheus.Counter
+	sources     []LineSource
+	categories  []errorlog.Category
+	wg          sync.WaitGroup
+	once        sync.Once
+}
+
+// NewErrorLogCollector creates an ErrorLogCollector which parses the lines of
+// the sources once started. Entries are counted by severity and by the
+// categories their message matches.
+func NewErrorLogCollector(sources []LineSource, categories []errorlog.Category, namespace string, constLabels map[string]string, logger log.Logger) *ErrorLogCollector {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &ErrorLogCollector{
+		logger: logger,
+		ctx:    ctx,
+		cancel: cancel,
+		entries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "error_log",
+			Name:        "entries_total",
+			Help:        "Entries written to the error log",
+			ConstLabels: constLabels,
+		}, []string{"severity"}),
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "error_log",
+			Name:        "events_total",
+			Help:        "Error log entries matching a category",
+			ConstLabels: constLabels,
+		}, []string{"category", "upstream"}),
+		parseErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "error_log",
+			Name:        "parse_errors_total",
+			Help:        "Error log lines which are not error log entries",
+			ConstLabels: constLabels,
+		}),
+		sources:    sources,
+		categories: categories,
+	}
+	// Initialize the severities, so rate() works from the first entry.
+	for _, severity := range errorlog.Severities {
+		c.entries.WithLabelValues(severity)
+	}
+	return c
+}
+
+// Start reads the sources in the background until Stop is called.
+func (c *ErrorLogCollector) Start() {
+	c.once.Do(func() {
+		for _, source := range c.sources {
+			c.wg.Add(1)
+			go func(source LineSource) {
+				defer c.wg.Done()
+				source.Run(c.ctx, c.Observe)
+			}(source)
+		}
+	})
+}
+
+// Stop stops reading the sources and waits for them to return.
+func (c *ErrorLogCollector) Stop() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+// Observe counts the entry of an error log line.
+func (c *ErrorLogCollector) Observe(line string) {
+	e, err := errorlog.Parse(line)
+	if err != nil {
+		c.parseErrors.Inc()
+		level.Debug(c.logger).Log("msg", "Error parsing error log line", "line", line, "error", err.Error())
+		return
+	}
+
+	c.entries.WithLabelValues(e.Severity).Inc()
+	for _, category := range errorlog.Match(c.categories, e) {
+		c.events.WithLabelValues(category, e.Upstream).Inc()
+	}
+}
+
+// Describe sends the descriptors of the error log metrics to the provided
+// channel.
+func (c *ErrorLogCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.entries.Describe(ch)
+	c.events.Describe(ch)
+	c.parseErrors.Describe(ch)
+}
+
+// Collect sends the error log metrics to the provided channel.
+func (c *ErrorLogCollector) Collect(ch chan<- prometheus.Metric) {
+	c.entries.Collect(ch)
+	c.events.Collect(ch)
+	c.parseErrors.Collect(ch)
+}
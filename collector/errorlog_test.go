@@ -0,0 +1,81 @@
+package collector
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/nginxinc/nginx-prometheus-exporter/errorlog"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestErrorLogCollector(t *testing.T) {
+	t.Parallel()
+
+	categories := append([]errorlog.Category{{Name: "ssl_handshake", Regexp: regexp.MustCompile(`SSL_do_handshake\(\) failed`)}}, errorlog.Categories...)
+	c := NewErrorLogCollector(nil, categories, "nginx", map[string]string{"tier": "edge"}, log.NewNopLogger())
+	for _, line := range []string{
+		`2023/10/10 13:55:36 [error] 31#31: *5 upstream timed out (110: Connection timed out) while reading response header from upstream, client: 10.0.0.9, server: example.com, request: "GET / HTTP/1.1", upstream: "http://10.0.0.2:8080/", host: "example.com"`,
+		`2023/10/10 13:55:37 [error] 31#31: *6 upstream timed out (110: Connection timed out) while reading response header from upstream, client: 10.0.0.9, server: example.com, request: "GET / HTTP/1.1", upstream: "http://10.0.0.2:8080/", host: "example.com"`,
+		`2023/10/10 13:55:38 [alert] 1#1: worker process 42 exited on signal 11 (core dumped)`,
+		`2023/10/10 13:55:39 [crit] 31#31: *8 SSL_do_handshake() failed (SSL: error:0A00006C:SSL routines::bad key share) while SSL handshaking, client: 10.0.0.9, server: 0.0.0.0:443`,
+		`not an error log line`,
+	} {
+		c.Observe(line)
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+
+	tests := []struct {
+		labels map[string]string
+		name   string
+		want   float64
+	}{
+		{name: "nginx_error_log_entries_total", labels: map[string]string{"severity": "error", "tier": "edge"}, want: 2},
+		{name: "nginx_error_log_entries_total", labels: map[string]string{"severity": "alert"}, want: 1},
+		{name: "nginx_error_log_entries_total", labels: map[string]string{"severity": "emerg"}, want: 0},
+		{name: "nginx_error_log_events_total", labels: map[string]string{"category": "upstream_timed_out", "upstream": "10.0.0.2:8080"}, want: 2},
+		{name: "nginx_error_log_events_total", labels: map[string]string{"category": "worker_exited_on_signal", "upstream": ""}, want: 1},
+		{name: "nginx_error_log_events_total", labels: map[string]string{"category": "ssl_handshake", "upstream": ""}, want: 1},
+		{name: "nginx_error_log_parse_errors_total", want: 1},
+	}
+	for _, tt := range tests {
+		got, ok := metricValue(families, tt.name, tt.labels)
+		if !ok {
+			t.Errorf("%s%v is missing", tt.name, tt.labels)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s%v = %v, want %v", tt.name, tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestErrorLogCollectorStart(t *testing.T) {
+	t.Parallel()
+
+	source := lineSource{`2023/10/10 13:55:36 [emerg] 1#1: bind() to 0.0.0.0:80 failed (98: Address already in use)`}
+	c := NewErrorLogCollector([]LineSource{source}, errorlog.Categories, "nginx", nil, log.NewNopLogger())
+	c.Start()
+	defer c.Stop()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	for i := 0; i < 100; i++ {
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("Gather() returned error: %v", err)
+		}
+		if got, ok := metricValue(families, "nginx_error_log_entries_total", map[string]string{"severity": "emerg"}); ok && got == 1 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("the line of the source was not counted")
+}
@@ -9,6 +9,9 @@ const (
 	nginxDown = 0
 )
 
+// exporterNamespace is the namespace of the metrics about the exporter itself.
+const exporterNamespace = "nginx_exporter"
+
 func newGlobalMetric(namespace string, metricName string, docString string, constLabels map[string]string) *prometheus.Desc {
 	return prometheus.NewDesc(namespace+"_"+metricName, docString, nil, constLabels)
 }
@@ -22,6 +25,23 @@ func newUpMetric(namespace string, constLabels map[string]string) prometheus.Gau
 	})
 }
 
+// describeCollector sends the descriptors of c to ch and reports whether it
+// sent any. Collectors wrapping an unchecked collector must stay unchecked.
+func describeCollector(c prometheus.Collector, ch chan<- *prometheus.Desc) bool {
+	descs := make(chan *prometheus.Desc)
+	go func() {
+		c.Describe(descs)
+		close(descs)
+	}()
+
+	described := false
+	for desc := range descs {
+		ch <- desc
+		described = true
+	}
+	return described
+}
+
 // MergeLabels merges two maps of labels.
 func MergeLabels(a map[string]string, b map[string]string) map[string]string {
 	c := make(map[string]string)
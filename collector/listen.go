@@ -0,0 +1,238 @@
+package collector
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/nginxinc/nginx-prometheus-exporter/nginxconf"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/procfs"
+)
+
+// tcpListen is the state of listen sockets in /proc/net/tcp.
+const tcpListen = 0x0A
+
+// ListenCollector collects the accept queues of the listen sockets of NGINX
+// from /proc/net. It implements prometheus.Collector interface.
+//
+// The listen sockets are matched by the addresses of the listen directives
+// of an NGINX configuration file or of the exporter flags. The sockets are
+// read from the network namespace of the exporter, which must be the one of
+// NGINX.
+type ListenCollector struct {
+	logger     log.Logger
+	fs         procfs.FS
+	metrics    map[string]*prometheus.Desc
+	procRoot   string
+	configPath string
+	addresses  []string
+	mutex      sync.Mutex
+}
+
+// NewListenCollector creates a ListenCollector which reads the proc
+// filesystem mounted at procRoot. The sockets listening on addresses, in the
+// format of the listen directive, and, when configPath is set, on the
+// addresses of the configuration file at configPath are reported.
+func NewListenCollector(procRoot, configPath string, addresses []string, namespace string, constLabels map[string]string, logger log.Logger) (*ListenCollector, error) {
+	procFS, err := procfs.NewFS(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	socketLabels := []string{"address"}
+	return &ListenCollector{
+		logger:     logger,
+		fs:         procFS,
+		procRoot:   procRoot,
+		configPath: configPath,
+		addresses:  addresses,
+		metrics: map[string]*prometheus.Desc{
+			"sockets":         newListenMetric(namespace, "sockets", "Number of listen sockets of the address, several with reuseport", socketLabels, constLabels),
+			"queue_length":    newListenMetric(namespace, "queue_length", "Number of connections waiting in the accept queues of the listen sockets of the address", socketLabels, constLabels),
+			"backlog":         newListenMetric(namespace, "backlog", "Maximum number of connections of the accept queues of the listen sockets of the address", socketLabels, constLabels),
+			"overflows_total": newListenMetric(namespace, "overflows_total", "Times a connection was dropped because the accept queue of a listen socket of the host was full", nil, constLabels),
+			"drops_total":     newListenMetric(namespace, "drops_total", "Connections dropped by the listen sockets of the host, including the overflows", nil, constLabels),
+		},
+	}, nil
+}
+
+func newListenMetric(namespace, metricName, docString string, variableLabelNames []string, constLabels prometheus.Labels) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "listen", metricName), docString, variableLabelNames, constLabels)
+}
+
+// Describe sends the descriptors of the listen metrics to the provided
+// channel.
+func (c *ListenCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.metrics {
+		ch <- m
+	}
+}
+
+// Collect reads the listen sockets from /proc/net and sends their metrics to
+// the provided channel.
+func (c *ListenCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	addresses := slices.Clone(c.addresses)
+	if c.configPath != "" {
+		cfg, err := nginxConfigs.Load(c.configPath)
+		if err != nil {
+			level.Error(c.logger).Log("msg", "Error parsing the NGINX configuration", "error", err.Error())
+		} else {
+			for _, l := range nginxconf.Summarize(cfg).Listens {
+				addresses = append(addresses, l.Address)
+			}
+		}
+	}
+	var matchers []listenAddress
+	for _, address := range addresses {
+		m, err := parseListenAddress(address)
+		if err != nil {
+			level.Debug(c.logger).Log("msg", "Skipping listen address", "address", address, "error", err.Error())
+			continue
+		}
+		matchers = append(matchers, m)
+	}
+
+	type listener struct {
+		sockets float64
+		queue   float64
+		backlog float64
+	}
+	listeners := make(map[string]*listener)
+	var addressOrder []string
+	for _, read := range []func() (procfs.NetTCP, error){c.fs.NetTCP, c.fs.NetTCP6} {
+		sockets, err := read()
+		if err != nil {
+			// /proc/net/tcp6 is missing when IPv6 is disabled.
+			if !errors.Is(err, fs.ErrNotExist) {
+				level.Error(c.logger).Log("msg", "Error reading the TCP sockets", "error", err.Error())
+			}
+			continue
+		}
+		for _, s := range sockets {
+			if s.St != tcpListen || !matchesAny(matchers, s.LocalAddr, s.LocalPort) {
+				continue
+			}
+			address := net.JoinHostPort(s.LocalAddr.String(), strconv.FormatUint(s.LocalPort, 10))
+			l, ok := listeners[address]
+			if !ok {
+				l = &listener{}
+				listeners[address] = l
+				addressOrder = append(addressOrder, address)
+			}
+			// The receive queue of a listen socket is its accept queue, and
+			// the transmit queue its backlog.
+			l.sockets++
+			l.queue += float64(s.RxQueue)
+			l.backlog += float64(s.TxQueue)
+		}
+	}
+	for _, address := range addressOrder {
+		l := listeners[address]
+		ch <- prometheus.MustNewConstMetric(c.metrics["sockets"], prometheus.GaugeValue, l.sockets, address)
+		ch <- prometheus.MustNewConstMetric(c.metrics["queue_length"], prometheus.GaugeValue, l.queue, address)
+		ch <- prometheus.MustNewConstMetric(c.metrics["backlog"], prometheus.GaugeValue, l.backlog, address)
+	}
+
+	netstat, err := readNetstat(filepath.Join(c.procRoot, "net", "netstat"))
+	if err != nil {
+		level.Error(c.logger).Log("msg", "Error reading the network statistics", "error", err.Error())
+		return
+	}
+	if v, ok := netstat["TcpExt"]["ListenOverflows"]; ok {
+		ch <- prometheus.MustNewConstMetric(c.metrics["overflows_total"], prometheus.CounterValue, v)
+	}
+	if v, ok := netstat["TcpExt"]["ListenDrops"]; ok {
+		ch <- prometheus.MustNewConstMetric(c.metrics["drops_total"], prometheus.CounterValue, v)
+	}
+}
+
+// listenAddress matches the sockets of a listen directive. A nil IP matches
+// every address of the port.
+type listenAddress struct {
+	ip   net.IP
+	port uint64
+}
+
+// parseListenAddress parses the address of a listen directive, such as "80",
+// "127.0.0.1:8080", "*:80" or "[::]:443". Host names match every address of
+// the port, and an address without a port has the port 80, like in NGINX.
+func parseListenAddress(address string) (listenAddress, error) {
+	if strings.HasPrefix(address, "unix:") {
+		return listenAddress{}, errors.New("unix domain sockets are not supported")
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		if _, err := strconv.ParseUint(address, 10, 16); err == nil {
+			host, port = "", address
+		} else {
+			host, port = strings.Trim(address, "[]"), "80"
+		}
+	}
+	n, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return listenAddress{}, fmt.Errorf("invalid port %q", port)
+	}
+	return listenAddress{ip: net.ParseIP(host), port: n}, nil
+}
+
+func (a listenAddress) matches(ip net.IP, port uint64) bool {
+	return a.port == port && (a.ip == nil || a.ip.Equal(ip))
+}
+
+func matchesAny(addresses []listenAddress, ip net.IP, port uint64) bool {
+	for _, a := range addresses {
+		if a.matches(ip, port) {
+			return true
+		}
+	}
+	return false
+}
+
+// readNetstat reads a file in the format of /proc/net/netstat, which has a
+// line of names and a line of values for every protocol.
+func readNetstat(path string) (map[string]map[string]float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stats := make(map[string]map[string]float64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		names := strings.Fields(scanner.Text())
+		if len(names) == 0 {
+			continue
+		}
+		if !scanner.Scan() {
+			return nil, fmt.Errorf("%s: missing values of %s", path, names[0])
+		}
+		values := strings.Fields(scanner.Text())
+		if len(names) != len(values) || names[0] != values[0] {
+			return nil, fmt.Errorf("%s: names and values do not match", path)
+		}
+		protocol := strings.TrimSuffix(names[0], ":")
+		stats[protocol] = make(map[string]float64, len(names)-1)
+		for i := 1; i < len(names); i++ {
+			v, err := strconv.ParseFloat(values[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid value of %s %s: %w", path, protocol, names[i], err)
+			}
+			stats[protocol][names[i]] = v
+		}
+	}
+	return stats, scanner.Err()
+}
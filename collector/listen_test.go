@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"net"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestListenCollector(t *testing.T) {
+	t.Parallel()
+
+	// The configuration listens on port 443, and the flag adds port 80.
+	c, err := NewListenCollector("testdata/proc", "testdata/nginx.conf", []string{"80"}, "nginx", nil, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+
+	tests := []struct {
+		labels map[string]string
+		name   string
+		want   float64
+	}{
+		{name: "nginx_listen_sockets", labels: map[string]string{"address": "0.0.0.0:80"}, want: 2},
+		{name: "nginx_listen_queue_length", labels: map[string]string{"address": "0.0.0.0:80"}, want: 5},
+		{name: "nginx_listen_backlog", labels: map[string]string{"address": "0.0.0.0:80"}, want: 1022},
+		{name: "nginx_listen_sockets", labels: map[string]string{"address": "[::]:443"}, want: 1},
+		{name: "nginx_listen_queue_length", labels: map[string]string{"address": "[::]:443"}, want: 7},
+		{name: "nginx_listen_backlog", labels: map[string]string{"address": "[::]:443"}, want: 511},
+		{name: "nginx_listen_overflows_total", want: 12},
+		{name: "nginx_listen_drops_total", want: 15},
+	}
+	for _, tt := range tests {
+		got, ok := metricValue(families, tt.name, tt.labels)
+		if !ok {
+			t.Errorf("%s%v is missing", tt.name, tt.labels)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s%v = %v, want %v", tt.name, tt.labels, got, tt.want)
+		}
+	}
+	for _, address := range []string{"127.0.0.1:8080", "0.0.0.0:22"} {
+		if _, ok := metricValue(families, "nginx_listen_sockets", map[string]string{"address": address}); ok {
+			t.Errorf("nginx_listen_sockets reported for %s", address)
+		}
+	}
+}
+
+func TestParseListenAddress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		address string
+		ip      net.IP
+		port    uint64
+		wantErr bool
+	}{
+		{address: "80", port: 80},
+		{address: "*:8080", port: 8080},
+		{address: "127.0.0.1:8080", ip: net.ParseIP("127.0.0.1"), port: 8080},
+		{address: "[::1]:443", ip: net.ParseIP("::1"), port: 443},
+		{address: "127.0.0.1", ip: net.ParseIP("127.0.0.1"), port: 80},
+		{address: "[::1]", ip: net.ParseIP("::1"), port: 80},
+		{address: "localhost:8080", port: 8080},
+		{address: "unix:/run/nginx.sock", wantErr: true},
+		{address: "127.0.0.1:http", wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.address, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseListenAddress(tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseListenAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (!got.ip.Equal(tt.ip) || got.port != tt.port) {
+				t.Errorf("parseListenAddress() = %v:%d, want %v:%d", got.ip, got.port, tt.ip, tt.port)
+			}
+		})
+	}
+}
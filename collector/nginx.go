@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"sync"
 
 	"github.com/go-kit/log"
@@ -10,19 +11,36 @@ import (
 )
 
 // NginxCollector collects NGINX metrics. It implements prometheus.Collector interface.
+//
+// It reports the restarts of NGINX, which reset the counters of the
+// stub_status page, detected by a RestartTracker.
 type NginxCollector struct {
-	upMetric    prometheus.Gauge
-	logger      log.Logger
-	nginxClient *client.NginxClient
-	metrics     map[string]*prometheus.Desc
-	mutex       sync.Mutex
+	upMetric       prometheus.Gauge
+	logger         log.Logger
+	nginxClient    *client.NginxClient
+	scrapeURI      string
+	maxConnections func() (float64, bool)
+	restarts       *RestartTracker
+	response       *client.ResponseInfo
+	metrics        map[string]*prometheus.Desc
+	mutex          sync.Mutex
 }
 
-// NewNginxCollector creates an NginxCollector.
-func NewNginxCollector(nginxClient *client.NginxClient, namespace string, constLabels map[string]string, logger log.Logger) *NginxCollector {
+// NewNginxCollector creates an NginxCollector for the target at scrapeURI.
+// When maxConnections is not nil, it returns the maximum number of
+// connections of NGINX to report the utilization of the connections. The
+// restarts of the target are tracked by restarts under the scrapeURI, or by
+// the collector alone when it is nil.
+func NewNginxCollector(nginxClient *client.NginxClient, scrapeURI string, maxConnections func() (float64, bool), restarts *RestartTracker, namespace string, constLabels map[string]string, logger log.Logger) *NginxCollector {
+	if restarts == nil {
+		restarts = NewRestartTracker()
+	}
 	return &NginxCollector{
-		nginxClient: nginxClient,
-		logger:      logger,
+		nginxClient:    nginxClient,
+		scrapeURI:      scrapeURI,
+		maxConnections: maxConnections,
+		restarts:       restarts,
+		logger:         logger,
 		metrics: map[string]*prometheus.Desc{
 			"connections_active":   newGlobalMetric(namespace, "connections_active", "Active client connections", constLabels),
 			"connections_accepted": newGlobalMetric(namespace, "connections_accepted", "Accepted client connections", constLabels),
@@ -31,11 +49,29 @@ func NewNginxCollector(nginxClient *client.NginxClient, namespace string, constL
 			"connections_writing":  newGlobalMetric(namespace, "connections_writing", "Connections where NGINX is writing the response back to the client", constLabels),
 			"connections_waiting":  newGlobalMetric(namespace, "connections_waiting", "Idle client connections", constLabels),
 			"http_requests_total":  newGlobalMetric(namespace, "http_requests_total", "Total http requests", constLabels),
+			"http_request_time_seconds_total": newGlobalMetric(namespace, "http_request_time_seconds_total",
+				"Total time spent processing http requests, reported by Tengine", constLabels),
+			"connections_utilization_ratio": newGlobalMetric(namespace, "connections_utilization_ratio",
+				"Active client connections divided by the maximum number of connections of the NGINX configuration", constLabels),
+			"restarts_detected_total": newGlobalMetric(namespace, "restarts_detected_total",
+				"Restarts of NGINX detected from counters of the stub_status page going backwards", constLabels),
+			"last_restart_detected_timestamp_seconds": newGlobalMetric(namespace, "last_restart_detected_timestamp_seconds",
+				"Time of the scrape which detected the last restart of NGINX since unix epoch in seconds", constLabels),
 		},
 		upMetric: newUpMetric(namespace, constLabels),
 	}
 }
 
+func (c *NginxCollector) upDesc() *prometheus.Desc {
+	return c.upMetric.Desc()
+}
+
+func (c *NginxCollector) responseInfo() *client.ResponseInfo {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.response
+}
+
 // Describe sends the super-set of all possible descriptors of NGINX metrics
 // to the provided channel.
 func (c *NginxCollector) Describe(ch chan<- *prometheus.Desc) {
@@ -48,15 +84,24 @@ func (c *NginxCollector) Describe(ch chan<- *prometheus.Desc) {
 
 // Collect fetches metrics from NGINX and sends them to the provided channel.
 func (c *NginxCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+// CollectContext is like Collect but cancels the request to NGINX when ctx is done.
+func (c *NginxCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	_ = c.collect(ctx, ch)
+}
+
+func (c *NginxCollector) collect(ctx context.Context, ch chan<- prometheus.Metric) error {
 	c.mutex.Lock() // To protect metrics from concurrent collects
 	defer c.mutex.Unlock()
 
-	stats, err := c.nginxClient.GetStubStats()
+	stats, err := c.nginxClient.GetStubStatsContext(ctx)
 	if err != nil {
 		c.upMetric.Set(nginxDown)
 		ch <- c.upMetric
 		level.Error(c.logger).Log("msg", "Error getting stats", "error", err.Error())
-		return
+		return err
 	}
 
 	c.upMetric.Set(nginxUp)
@@ -76,4 +121,30 @@ func (c *NginxCollector) Collect(ch chan<- prometheus.Metric) {
 		prometheus.GaugeValue, float64(stats.Connections.Waiting))
 	ch <- prometheus.MustNewConstMetric(c.metrics["http_requests_total"],
 		prometheus.CounterValue, float64(stats.Requests))
+	if stats.RequestTime != nil {
+		ch <- prometheus.MustNewConstMetric(c.metrics["http_request_time_seconds_total"],
+			prometheus.CounterValue, float64(*stats.RequestTime)/1000)
+	}
+
+	if c.maxConnections != nil {
+		if maxConnections, ok := c.maxConnections(); ok && maxConnections > 0 {
+			ch <- prometheus.MustNewConstMetric(c.metrics["connections_utilization_ratio"],
+				prometheus.GaugeValue, float64(stats.Connections.Active)/maxConnections)
+		}
+	}
+
+	c.response = stats.Response
+
+	restarts, lastRestart, restarted := c.restarts.observe(c.scrapeURI, stats)
+	if restarted {
+		level.Info(c.logger).Log("msg", "Detected a restart of NGINX", "requests", stats.Requests)
+	}
+	ch <- prometheus.MustNewConstMetric(c.metrics["restarts_detected_total"],
+		prometheus.CounterValue, restarts)
+	if lastRestart > 0 {
+		ch <- prometheus.MustNewConstMetric(c.metrics["last_restart_detected_timestamp_seconds"],
+			prometheus.GaugeValue, lastRestart)
+	}
+
+	return nil
 }
@@ -0,0 +1,161 @@
+package collector
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/nginxinc/nginx-prometheus-exporter/client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// scraper is implemented by the collectors which report the outcome of a scrape.
+type scraper interface {
+	collect(ctx context.Context, ch chan<- prometheus.Metric) error
+}
+
+// upReporter is implemented by the collectors which report an up metric.
+type upReporter interface {
+	upDesc() *prometheus.Desc
+}
+
+// NginxAutoCollector detects whether a target serves the stub_status page or
+// the NGINX Plus API and delegates to the matching collector. The API is
+// detected again after a failed scrape. It implements prometheus.Collector
+// interface as an unchecked collector, since its metrics depend on the
+// detected API.
+//
+// The up metric keeps the namespace of the NginxAutoCollector, so it does not
+// change its name with the detected API.
+type NginxAutoCollector struct {
+	upMetric     prometheus.Gauge
+	logger       log.Logger
+	collector    prometheus.Collector
+	detect       func(ctx context.Context) (client.API, error)
+	newCollector func(api client.API) (prometheus.Collector, error)
+	modeMetric   *prometheus.Desc
+	api          client.API
+	detected     bool
+	mutex        sync.Mutex
+}
+
+// NewNginxAutoCollector creates an NginxAutoCollector. The detect function
+// reports the API of the target and newCollector creates the collector for
+// it. The namespace is used for the up metric, which replaces the up metric of
+// the detected collector.
+func NewNginxAutoCollector(detect func(ctx context.Context) (client.API, error), newCollector func(api client.API) (prometheus.Collector, error),
+	namespace string, constLabels map[string]string, logger log.Logger,
+) *NginxAutoCollector {
+	return &NginxAutoCollector{
+		detect:       detect,
+		newCollector: newCollector,
+		logger:       logger,
+		modeMetric: prometheus.NewDesc(prometheus.BuildFQName(exporterNamespace, "", "detected_mode_info"),
+			"Mode detected for the target: oss for the stub_status page, plus for the NGINX Plus API", []string{"mode"}, constLabels),
+		upMetric: newUpMetric(namespace, constLabels),
+	}
+}
+
+// Describe sends no descriptors, which makes NginxAutoCollector an unchecked collector.
+func (c *NginxAutoCollector) Describe(chan<- *prometheus.Desc) {}
+
+// Collect detects the API of the target if needed, then fetches its metrics
+// and sends them to the provided channel.
+func (c *NginxAutoCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+// CollectContext is like Collect but cancels the requests to the target when
+// ctx is done.
+func (c *NginxAutoCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	_ = c.collect(ctx, ch)
+}
+
+func (c *NginxAutoCollector) collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.detected {
+		if err := c.detectCollector(ctx); err != nil {
+			level.Warn(c.logger).Log("msg", "Error detecting the API", "error", err.Error())
+			if c.collector == nil {
+				c.upMetric.Set(nginxDown)
+				ch <- c.upMetric
+				return err
+			}
+			// Let the collector of the previously detected API report the failure.
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.modeMetric, prometheus.GaugeValue, 1, string(c.api))
+
+	err := c.collectDetected(ctx, ch)
+	if err != nil {
+		c.detected = false
+		c.upMetric.Set(nginxDown)
+	} else {
+		c.upMetric.Set(nginxUp)
+	}
+	ch <- c.upMetric
+	return err
+}
+
+// responseInfo returns the response of the last successful scrape of the
+// detected collector, when it keeps it.
+func (c *NginxAutoCollector) responseInfo() *client.ResponseInfo {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if r, ok := c.collector.(responseReporter); ok {
+		return r.responseInfo()
+	}
+	return nil
+}
+
+// collectDetected sends the metrics of the detected collector to ch, except
+// its up metric.
+func (c *NginxAutoCollector) collectDetected(ctx context.Context, ch chan<- prometheus.Metric) error {
+	var up *prometheus.Desc
+	if r, ok := c.collector.(upReporter); ok {
+		up = r.upDesc()
+	}
+	metrics := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	go func() {
+		for m := range metrics {
+			if up == nil || m.Desc() != up {
+				ch <- m
+			}
+		}
+		close(done)
+	}()
+
+	var err error
+	if s, ok := c.collector.(scraper); ok {
+		err = s.collect(ctx, metrics)
+	} else {
+		CollectContext(ctx, c.collector, metrics)
+	}
+	close(metrics)
+	<-done
+	return err
+}
+
+func (c *NginxAutoCollector) detectCollector(ctx context.Context) error {
+	api, err := c.detect(ctx)
+	if err != nil {
+		return err
+	}
+	if c.collector == nil || api != c.api {
+		collector, err := c.newCollector(api)
+		if err != nil {
+			return err
+		}
+		level.Info(c.logger).Log("msg", "Detected API", "mode", api)
+		c.collector = collector
+		c.api = api
+	}
+	c.detected = true
+	return nil
+}
@@ -0,0 +1,122 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/nginxinc/nginx-prometheus-exporter/client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestNginxAutoCollector(t *testing.T) {
+	t.Parallel()
+
+	var api client.API
+	var detectErr error
+	detections := 0
+	detect := func(context.Context) (client.API, error) {
+		detections++
+		return api, detectErr
+	}
+	scrapers := make(map[client.API]*fakeScraper)
+	newCollector := func(api client.API) (prometheus.Collector, error) {
+		namespace := "nginx"
+		if api == client.APIPlus {
+			namespace = "nginxplus"
+		}
+		s := &fakeScraper{
+			upMetric: newUpMetric(namespace, nil),
+			active:   prometheus.NewGauge(prometheus.GaugeOpts{Namespace: namespace, Name: "connections_active", Help: "Active client connections"}),
+		}
+		scrapers[api] = s
+		return s, nil
+	}
+
+	c := NewNginxAutoCollector(detect, newCollector, "nginx", nil, log.NewNopLogger())
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	steps := []struct {
+		name           string
+		api            client.API
+		detectErr      error
+		scrapeErr      error
+		wantMode       client.API
+		wantMetric     string
+		wantUp         float64
+		wantDetections int
+	}{
+		{
+			name:           "detection fails",
+			detectErr:      errors.New("connection refused"),
+			wantUp:         nginxDown,
+			wantDetections: 1,
+		},
+		{
+			name:           "detects the stub_status page",
+			api:            client.APIStubStatus,
+			wantMode:       client.APIStubStatus,
+			wantMetric:     "nginx_connections_active",
+			wantUp:         nginxUp,
+			wantDetections: 2,
+		},
+		{
+			name:           "keeps the detected API",
+			api:            client.APIStubStatus,
+			wantMode:       client.APIStubStatus,
+			wantMetric:     "nginx_connections_active",
+			wantUp:         nginxUp,
+			wantDetections: 2,
+		},
+		{
+			name:           "scrape fails",
+			api:            client.APIStubStatus,
+			scrapeErr:      errors.New("connection refused"),
+			wantMode:       client.APIStubStatus,
+			wantUp:         nginxDown,
+			wantDetections: 2,
+		},
+		{
+			name:           "detects the NGINX Plus API again",
+			api:            client.APIPlus,
+			wantMode:       client.APIPlus,
+			wantMetric:     "nginxplus_connections_active",
+			wantUp:         nginxUp,
+			wantDetections: 3,
+		},
+	}
+	for _, step := range steps {
+		api, detectErr = step.api, step.detectErr
+		for _, s := range scrapers {
+			s.err = step.scrapeErr
+		}
+
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("%s: Gather() returned error: %v", step.name, err)
+		}
+
+		if detections != step.wantDetections {
+			t.Errorf("%s: detections = %d, want %d", step.name, detections, step.wantDetections)
+		}
+		if got, _ := metricValue(families, "nginx_up", nil); got != step.wantUp {
+			t.Errorf("%s: nginx_up = %v, want %v", step.name, got, step.wantUp)
+		}
+		if _, ok := metricValue(families, "nginxplus_up", nil); ok {
+			t.Errorf("%s: nginxplus_up reported, want only nginx_up", step.name)
+		}
+		for _, mode := range []client.API{client.APIStubStatus, client.APIPlus} {
+			_, ok := metricValue(families, "nginx_exporter_detected_mode_info", map[string]string{"mode": string(mode)})
+			if want := mode == step.wantMode; ok != want {
+				t.Errorf("%s: mode %q reported = %v, want %v", step.name, mode, ok, want)
+			}
+		}
+		if step.wantMetric != "" {
+			if _, ok := metricValue(families, step.wantMetric, nil); !ok {
+				t.Errorf("%s: %s not reported", step.name, step.wantMetric)
+			}
+		}
+	}
+}
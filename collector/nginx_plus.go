@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"sync"
@@ -8,6 +9,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	plusclient "github.com/nginxinc/nginx-plus-go-client/client"
+	"github.com/nginxinc/nginx-prometheus-exporter/client"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -37,7 +39,7 @@ type NginxPlusCollector struct {
 	logger                         log.Logger
 	cacheZoneMetrics               map[string]*prometheus.Desc
 	workerMetrics                  map[string]*prometheus.Desc
-	nginxClient                    *plusclient.NginxClient
+	getStats                       func(ctx context.Context) (*plusclient.Stats, error)
 	streamServerZoneMetrics        map[string]*prometheus.Desc
 	streamZoneSyncMetrics          map[string]*prometheus.Desc
 	streamUpstreamMetrics          map[string]*prometheus.Desc
@@ -278,8 +280,22 @@ func NewVariableLabelNames(upstreamServerVariableLabelNames []string, serverZone
 	}
 }
 
-// NewNginxPlusCollector creates an NginxPlusCollector.
+// NewNginxPlusCollector creates an NginxPlusCollector. The NGINX Plus API
+// client does not accept a context, so CollectContext cannot cancel its requests.
 func NewNginxPlusCollector(nginxClient *plusclient.NginxClient, namespace string, variableLabelNames VariableLabelNames, constLabels map[string]string, logger log.Logger) *NginxPlusCollector {
+	getStats := func(context.Context) (*plusclient.Stats, error) {
+		return nginxClient.GetStats()
+	}
+	return newNginxPlusCollector(getStats, namespace, variableLabelNames, constLabels, logger)
+}
+
+// NewNginxPlusContextCollector creates an NginxPlusCollector whose requests
+// are canceled when the context passed to CollectContext is done.
+func NewNginxPlusContextCollector(nginxClient *client.NginxPlusClient, namespace string, variableLabelNames VariableLabelNames, constLabels map[string]string, logger log.Logger) *NginxPlusCollector {
+	return newNginxPlusCollector(nginxClient.GetStats, namespace, variableLabelNames, constLabels, logger)
+}
+
+func newNginxPlusCollector(getStats func(ctx context.Context) (*plusclient.Stats, error), namespace string, variableLabelNames VariableLabelNames, constLabels map[string]string, logger log.Logger) *NginxPlusCollector {
 	upstreamServerVariableLabelNames := append(variableLabelNames.UpstreamServerVariableLabelNames, variableLabelNames.UpstreamServerPeerVariableLabelNames...)
 	streamUpstreamServerVariableLabelNames := append(variableLabelNames.StreamUpstreamServerVariableLabelNames, variableLabelNames.StreamUpstreamServerPeerVariableLabelNames...)
 	return &NginxPlusCollector{
@@ -290,7 +306,7 @@ func NewNginxPlusCollector(nginxClient *plusclient.NginxClient, namespace string
 		upstreamServerPeerLabels:       make(map[string][]string),
 		streamUpstreamServerPeerLabels: make(map[string][]string),
 		streamUpstreamServerLabels:     make(map[string][]string),
-		nginxClient:                    nginxClient,
+		getStats:                       getStats,
 		logger:                         logger,
 		totalMetrics: map[string]*prometheus.Desc{
 			"connections_accepted":  newGlobalMetric(namespace, "connections_accepted", "Accepted client connections", constLabels),
@@ -585,6 +601,10 @@ func NewNginxPlusCollector(nginxClient *plusclient.NginxClient, namespace string
 	}
 }
 
+func (c *NginxPlusCollector) upDesc() *prometheus.Desc {
+	return c.upMetric.Desc()
+}
+
 // Describe sends the super-set of all possible descriptors of NGINX Plus metrics
 // to the provided channel.
 func (c *NginxPlusCollector) Describe(ch chan<- *prometheus.Desc) {
@@ -639,15 +659,25 @@ func (c *NginxPlusCollector) Describe(ch chan<- *prometheus.Desc) {
 
 // Collect fetches metrics from NGINX Plus and sends them to the provided channel.
 func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+// CollectContext is like Collect but cancels the requests to the NGINX Plus
+// API when ctx is done.
+func (c *NginxPlusCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	_ = c.collect(ctx, ch)
+}
+
+func (c *NginxPlusCollector) collect(ctx context.Context, ch chan<- prometheus.Metric) error {
 	c.mutex.Lock() // To protect metrics from concurrent collects
 	defer c.mutex.Unlock()
 
-	stats, err := c.nginxClient.GetStats()
+	stats, err := c.getStats(ctx)
 	if err != nil {
 		c.upMetric.Set(nginxDown)
 		ch <- c.upMetric
 		level.Warn(c.logger).Log("msg", "Error getting stats", "error", err.Error())
-		return
+		return err
 	}
 
 	c.upMetric.Set(nginxUp)
@@ -1271,6 +1301,8 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 		ch <- prometheus.MustNewConstMetric(c.workerMetrics["http_requests_total"], prometheus.CounterValue, float64(worker.HTTP.HTTPRequests.Total), labelValues...)
 		ch <- prometheus.MustNewConstMetric(c.workerMetrics["http_requests_current"], prometheus.GaugeValue, float64(worker.HTTP.HTTPRequests.Current), labelValues...)
 	}
+
+	return nil
 }
 
 var upstreamServerStates = map[string]float64{
@@ -0,0 +1,74 @@
+package collector
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/nginxinc/nginx-prometheus-exporter/client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestNginxCollectorRestarts(t *testing.T) {
+	t.Parallel()
+
+	// Accepted connections, handled connections and requests of every scrape.
+	pages := [][3]int{
+		{100, 100, 300},
+		{150, 150, 400},
+		{10, 10, 20},
+		{12, 12, 25},
+	}
+	var scrape atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		p := pages[scrape.Load()]
+		fmt.Fprintf(w, "Active connections: 1 \nserver accepts handled requests\n %d %d %d \nReading: 0 Writing: 1 Waiting: 0 \n", p[0], p[1], p[2])
+	}))
+	t.Cleanup(srv.Close)
+
+	// Every scrape uses a new collector, like the probes, which shares the
+	// tracker.
+	restarts := NewRestartTracker()
+	wantRestarts := []float64{0, 0, 1, 1}
+	for i, want := range wantRestarts {
+		scrape.Store(int64(i))
+		c := NewNginxCollector(client.NewNginxClient(srv.Client(), srv.URL), srv.URL, nil, restarts, "nginx", nil, log.NewNopLogger())
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(c)
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("scrape %d: Gather() returned error: %v", i, err)
+		}
+		if got, ok := metricValue(families, "nginx_restarts_detected_total", nil); !ok || got != want {
+			t.Errorf("scrape %d: nginx_restarts_detected_total = %v, want %v", i, got, want)
+		}
+		_, ok := metricValue(families, "nginx_last_restart_detected_timestamp_seconds", nil)
+		if ok != (want > 0) {
+			t.Errorf("scrape %d: nginx_last_restart_detected_timestamp_seconds reported = %v, want %v", i, ok, want > 0)
+		}
+	}
+}
+
+func TestNginxCollectorUtilization(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "Active connections: 25 \nserver accepts handled requests\n 100 100 300 \nReading: 0 Writing: 1 Waiting: 24 \n")
+	}))
+	t.Cleanup(srv.Close)
+
+	maxConnections := func() (float64, bool) { return 100, true }
+	c := NewNginxCollector(client.NewNginxClient(srv.Client(), srv.URL), srv.URL, maxConnections, nil, "nginx", nil, log.NewNopLogger())
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+	if got, ok := metricValue(families, "nginx_connections_utilization_ratio", nil); !ok || got != 0.25 {
+		t.Errorf("nginx_connections_utilization_ratio = %v, want 0.25", got)
+	}
+}
@@ -0,0 +1,153 @@
+package collector
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/nginxinc/nginx-prometheus-exporter/nginxconf"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// reloadTimeTolerance is the time the configuration may be modified after
+// the reload time and still be deemed loaded. The start times of processes
+// are only precise to the second, like the boot time of /proc/stat, and
+// configuration management tools often reload right after writing the files.
+const reloadTimeTolerance = 1.0
+
+// nginxConfigs is shared by the collectors reading NGINX configuration files,
+// so the files are parsed once while they do not change.
+var nginxConfigs = nginxconf.NewCache()
+
+// NginxConfigCollector collects the settings of an NGINX configuration file
+// and the files it includes. It implements prometheus.Collector interface.
+type NginxConfigCollector struct {
+	logger     log.Logger
+	metrics    map[string]*prometheus.Desc
+	lastReload func() (float64, bool)
+	workers    func() (float64, bool)
+	path       string
+	mutex      sync.Mutex
+}
+
+// NewNginxConfigCollector creates an NginxConfigCollector which parses the
+// configuration file at path again when its files change. When lastReload is
+// set, the collector reports whether the files changed after the time it
+// returns, which is when NGINX loaded its running configuration. When workers
+// is set, it returns the number of running workers, reported as the worker
+// processes of "worker_processes auto".
+func NewNginxConfigCollector(path string, lastReload, workers func() (float64, bool), namespace string, constLabels map[string]string, logger log.Logger) *NginxConfigCollector {
+	return &NginxConfigCollector{
+		logger:     logger,
+		path:       path,
+		lastReload: lastReload,
+		workers:    workers,
+		metrics: map[string]*prometheus.Desc{
+			"parse_success":      newConfigMetric(namespace, "parse_success", "Whether the NGINX configuration was parsed", nil, constLabels),
+			"info":               newConfigMetric(namespace, "info", "SHA-256 hash of the paths and contents of the files of the NGINX configuration", []string{"sha256"}, constLabels),
+			"last_modified":      newConfigMetric(namespace, "last_modified_timestamp_seconds", "Newest modification time of the files of the NGINX configuration since unix epoch in seconds", nil, constLabels),
+			"pending_reload":     newConfigMetric(namespace, "pending_reload", "Whether the files of the NGINX configuration changed after NGINX loaded its running configuration", nil, constLabels),
+			"worker_processes":   newConfigMetric(namespace, "worker_processes", "Number of worker processes set by the NGINX configuration, or running for worker_processes auto", nil, constLabels),
+			"worker_connections": newConfigMetric(namespace, "worker_connections", "Maximum number of connections of a worker process set by the NGINX configuration", nil, constLabels),
+			"server_blocks":      newConfigMetric(namespace, "server_blocks", "Number of server blocks of the NGINX configuration", []string{"context"}, constLabels),
+			"server_name_info":   newConfigMetric(namespace, "server_name_info", "Server names of the NGINX configuration", []string{"context", "server_name"}, constLabels),
+			"upstream_servers":   newConfigMetric(namespace, "upstream_servers", "Number of servers of the upstreams of the NGINX configuration", []string{"context", "upstream"}, constLabels),
+			"listen_info":        newConfigMetric(namespace, "listen_info", "Addresses the server blocks of the NGINX configuration listen on", []string{"context", "address", "ssl"}, constLabels),
+		},
+	}
+}
+
+// Describe sends the descriptors of the configuration metrics to the provided
+// channel.
+func (c *NginxConfigCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.metrics {
+		ch <- m
+	}
+}
+
+// Collect parses the configuration and sends its metrics to the provided
+// channel.
+func (c *NginxConfigCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	cfg, err := nginxConfigs.Load(c.path)
+	if err != nil {
+		level.Error(c.logger).Log("msg", "Error parsing the NGINX configuration", "error", err.Error())
+		ch <- prometheus.MustNewConstMetric(c.metrics["parse_success"], prometheus.GaugeValue, 0)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.metrics["parse_success"], prometheus.GaugeValue, 1)
+	ch <- prometheus.MustNewConstMetric(c.metrics["info"], prometheus.GaugeValue, 1, cfg.Hash)
+	modified := float64(cfg.ModTime.UnixNano()) / float64(time.Second)
+	ch <- prometheus.MustNewConstMetric(c.metrics["last_modified"], prometheus.GaugeValue, modified)
+	if c.lastReload != nil {
+		if lastReload, ok := c.lastReload(); ok {
+			pending := 0.0
+			if modified > lastReload+reloadTimeTolerance {
+				pending = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.metrics["pending_reload"], prometheus.GaugeValue, pending)
+		}
+	}
+
+	s := nginxconf.Summarize(cfg)
+	if workers, ok := workerProcesses(s, c.workers); ok {
+		ch <- prometheus.MustNewConstMetric(c.metrics["worker_processes"], prometheus.GaugeValue, workers)
+	}
+	ch <- prometheus.MustNewConstMetric(c.metrics["worker_connections"], prometheus.GaugeValue, float64(s.WorkerConnections))
+	for context, servers := range s.Servers {
+		ch <- prometheus.MustNewConstMetric(c.metrics["server_blocks"], prometheus.GaugeValue, float64(servers), context)
+	}
+	for _, n := range s.ServerNames {
+		ch <- prometheus.MustNewConstMetric(c.metrics["server_name_info"], prometheus.GaugeValue, 1, n.Context, n.Name)
+	}
+	seenUpstreams := make(map[nginxconf.Upstream]bool)
+	for _, u := range s.Upstreams {
+		// NGINX rejects duplicate upstreams, but the metric must be unique
+		// even for an invalid configuration.
+		key := nginxconf.Upstream{Context: u.Context, Name: u.Name}
+		if seenUpstreams[key] {
+			continue
+		}
+		seenUpstreams[key] = true
+		ch <- prometheus.MustNewConstMetric(c.metrics["upstream_servers"], prometheus.GaugeValue, float64(u.Servers), u.Context, u.Name)
+	}
+	for _, l := range s.Listens {
+		ch <- prometheus.MustNewConstMetric(c.metrics["listen_info"], prometheus.GaugeValue, 1, l.Context, l.Address, strconv.FormatBool(l.SSL))
+	}
+}
+
+// ConfigMaxConnections returns a function reporting the maximum number of
+// connections NGINX accepts according to the configuration file at path,
+// which is parsed again when its files change. For "worker_processes auto",
+// the maximum is only known when workers is set and returns the number of
+// running workers.
+func ConfigMaxConnections(path string, workers func() (float64, bool)) func() (float64, bool) {
+	return func() (float64, bool) {
+		cfg, err := nginxConfigs.Load(path)
+		if err != nil {
+			return 0, false
+		}
+		s := nginxconf.Summarize(cfg)
+		processes, ok := workerProcesses(s, workers)
+		if !ok {
+			return 0, false
+		}
+		return processes * float64(s.WorkerConnections), true
+	}
+}
+
+// workerProcesses returns the worker processes of the configuration or, for
+// "worker_processes auto", the running workers returned by workers.
+func workerProcesses(s nginxconf.Summary, workers func() (float64, bool)) (float64, bool) {
+	if s.WorkerProcesses > 0 {
+		return float64(s.WorkerProcesses), true
+	}
+	if workers == nil {
+		return 0, false
+	}
+	return workers()
+}
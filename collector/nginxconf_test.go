@@ -0,0 +1,191 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestNginxConfigCollector(t *testing.T) {
+	t.Parallel()
+
+	c := NewNginxConfigCollector("testdata/nginx.conf", nil, nil, "nginx", nil, log.NewNopLogger())
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+
+	tests := []struct {
+		labels map[string]string
+		name   string
+		want   float64
+	}{
+		{name: "nginx_config_parse_success", want: 1},
+		{name: "nginx_config_info", want: 1},
+		{name: "nginx_config_worker_processes", want: 2},
+		{name: "nginx_config_worker_connections", want: 50},
+		{name: "nginx_config_server_blocks", labels: map[string]string{"context": "http"}, want: 1},
+		{name: "nginx_config_server_blocks", labels: map[string]string{"context": "stream"}, want: 0},
+		{name: "nginx_config_server_name_info", labels: map[string]string{"context": "http", "server_name": "example.com"}, want: 1},
+		{name: "nginx_config_upstream_servers", labels: map[string]string{"context": "http", "upstream": "backend"}, want: 2},
+		{name: "nginx_config_listen_info", labels: map[string]string{"context": "http", "address": "443", "ssl": "true"}, want: 1},
+	}
+	for _, tt := range tests {
+		got, ok := metricValue(families, tt.name, tt.labels)
+		if !ok {
+			t.Errorf("%s%v is missing", tt.name, tt.labels)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s%v = %v, want %v", tt.name, tt.labels, got, tt.want)
+		}
+	}
+
+	if got, ok := ConfigMaxConnections(c.path, nil)(); !ok || got != 100 {
+		t.Errorf("ConfigMaxConnections() = %v, %v, want 100, true", got, ok)
+	}
+}
+
+func TestNginxConfigCollectorParseError(t *testing.T) {
+	t.Parallel()
+
+	c := NewNginxConfigCollector("testdata/missing.conf", nil, nil, "nginx", nil, log.NewNopLogger())
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+	if got, ok := metricValue(families, "nginx_config_parse_success", nil); !ok || got != 0 {
+		t.Errorf("nginx_config_parse_success = %v, want 0", got)
+	}
+	if _, ok := ConfigMaxConnections(c.path, nil)(); ok {
+		t.Errorf("ConfigMaxConnections() succeeded without a configuration")
+	}
+}
+
+func TestNginxConfigCollectorWorkerProcessesAuto(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "nginx.conf")
+	if err := os.WriteFile(path, []byte("worker_processes auto;\nevents {\n    worker_connections 100;\n}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		workers  func() (float64, bool)
+		name     string
+		want     float64
+		reported bool
+	}{
+		{
+			name:     "running workers",
+			workers:  func() (float64, bool) { return 3, true },
+			want:     3,
+			reported: true,
+		},
+		{
+			name:    "master not found",
+			workers: func() (float64, bool) { return 0, false },
+		},
+		{
+			name: "no process metrics",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := NewNginxConfigCollector(path, nil, tt.workers, "nginx", nil, log.NewNopLogger())
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(c)
+			families, err := registry.Gather()
+			if err != nil {
+				t.Fatalf("Gather() returned error: %v", err)
+			}
+			got, ok := metricValue(families, "nginx_config_worker_processes", nil)
+			if ok != tt.reported || got != tt.want {
+				t.Errorf("nginx_config_worker_processes = %v (reported %v), want %v (reported %v)", got, ok, tt.want, tt.reported)
+			}
+
+			maxConnections, ok := ConfigMaxConnections(path, tt.workers)()
+			if ok != tt.reported || maxConnections != tt.want*100 {
+				t.Errorf("ConfigMaxConnections() = %v, %v, want %v, %v", maxConnections, ok, tt.want*100, tt.reported)
+			}
+		})
+	}
+}
+
+func TestNginxConfigCollectorPendingReload(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "nginx.conf")
+	if err := os.WriteFile(path, []byte("worker_processes 2;\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Unix(1700000100, 0)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		lastReload func() (float64, bool)
+		name       string
+		want       float64
+		reported   bool
+	}{
+		{
+			name:       "reloaded after the change",
+			lastReload: func() (float64, bool) { return 1700000200, true },
+			want:       0,
+			reported:   true,
+		},
+		{
+			name:       "reloaded in the same second",
+			lastReload: func() (float64, bool) { return 1700000099.5, true },
+			want:       0,
+			reported:   true,
+		},
+		{
+			name:       "changed after the reload",
+			lastReload: func() (float64, bool) { return 1700000000, true },
+			want:       1,
+			reported:   true,
+		},
+		{
+			name:       "master not found",
+			lastReload: func() (float64, bool) { return 0, false },
+		},
+		{
+			name: "no process metrics",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := NewNginxConfigCollector(path, tt.lastReload, nil, "nginx", nil, log.NewNopLogger())
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(c)
+			families, err := registry.Gather()
+			if err != nil {
+				t.Fatalf("Gather() returned error: %v", err)
+			}
+			if got, ok := metricValue(families, "nginx_config_last_modified_timestamp_seconds", nil); !ok || got != 1700000100 {
+				t.Errorf("nginx_config_last_modified_timestamp_seconds = %v, want 1700000100", got)
+			}
+			got, ok := metricValue(families, "nginx_config_pending_reload", nil)
+			if ok != tt.reported || got != tt.want {
+				t.Errorf("nginx_config_pending_reload = %v (reported %v), want %v (reported %v)", got, ok, tt.want, tt.reported)
+			}
+		})
+	}
+}
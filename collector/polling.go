@@ -0,0 +1,181 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// PollingCollector polls a collector in the background and serves the metrics
+// of its last successful poll, so scrapes do not reach the target. The metrics
+// of a failed poll, such as the up metric and the scrape errors, replace those
+// of the successful poll, whose other metrics are kept. Once the last
+// successful poll is older than the max age, it reports the target down and
+// drops the metrics of the successful poll. It implements prometheus.Collector
+// interface.
+type PollingCollector struct {
+	collector   prometheus.Collector
+	logger      log.Logger
+	ctx         context.Context //nolint:containedctx // canceled by Stop
+	cancel      context.CancelFunc
+	now         func() time.Time
+	upMetric    *prometheus.Desc
+	pollMetric  *prometheus.Desc
+	lastSuccess time.Time
+	metrics     []prometheus.Metric
+	// failed holds the metrics of the polls failed since the last successful
+	// poll.
+	failed   []prometheus.Metric
+	interval time.Duration
+	maxAge   time.Duration
+	once     sync.Once
+	mutex    sync.RWMutex
+}
+
+// NewPollingCollector creates a PollingCollector which polls c every interval
+// once started. The namespace and the const labels must be those of the up
+// metric of c.
+func NewPollingCollector(c prometheus.Collector, interval, maxAge time.Duration, namespace string, constLabels map[string]string, logger log.Logger) *PollingCollector {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &PollingCollector{
+		collector: c,
+		logger:    logger,
+		ctx:       ctx,
+		cancel:    cancel,
+		now:       time.Now,
+		upMetric:  newUpMetric(namespace, constLabels).Desc(),
+		pollMetric: prometheus.NewDesc(prometheus.BuildFQName(exporterNamespace, "", "last_successful_poll_timestamp_seconds"),
+			"Timestamp of the last successful poll of the target", nil, constLabels),
+		interval: interval,
+		maxAge:   maxAge,
+	}
+}
+
+// Start polls the target right away and then every interval until Stop is called.
+func (c *PollingCollector) Start() {
+	c.once.Do(func() {
+		go func() {
+			ticker := time.NewTicker(c.interval)
+			defer ticker.Stop()
+
+			for {
+				c.poll(c.ctx)
+				select {
+				case <-c.ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	})
+}
+
+// Stop stops polling and cancels a running poll.
+func (c *PollingCollector) Stop() {
+	c.cancel()
+}
+
+// Describe sends the descriptors of the polled collector and of the poll
+// timestamp metric to the provided channel. When the polled collector is
+// unchecked, so is the PollingCollector.
+func (c *PollingCollector) Describe(ch chan<- *prometheus.Desc) {
+	if describeCollector(c.collector, ch) {
+		ch <- c.pollMetric
+	}
+}
+
+// Collect sends the metrics of the last successful poll, updated by those of
+// a later failed poll, to the provided channel. Once the successful poll is
+// missing or too old, only the metrics of the failed poll are sent, or a down
+// up metric without them.
+func (c *PollingCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if !c.lastSuccess.IsZero() {
+		ch <- prometheus.MustNewConstMetric(c.pollMetric, prometheus.GaugeValue, float64(c.lastSuccess.UnixNano())/1e9)
+	}
+	if c.lastSuccess.IsZero() || c.now().Sub(c.lastSuccess) > c.maxAge {
+		if c.failed == nil {
+			ch <- prometheus.MustNewConstMetric(c.upMetric, prometheus.GaugeValue, nginxDown)
+			return
+		}
+		for _, m := range c.failed {
+			ch <- m
+		}
+		return
+	}
+
+	replaced := make(map[*prometheus.Desc]bool, len(c.failed))
+	for _, m := range c.failed {
+		replaced[m.Desc()] = true
+		ch <- m
+	}
+	for _, m := range c.metrics {
+		if !replaced[m.Desc()] {
+			ch <- m
+		}
+	}
+}
+
+func (c *PollingCollector) poll(ctx context.Context) {
+	ch := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	var metrics []prometheus.Metric
+	go func() {
+		for m := range ch {
+			metrics = append(metrics, freezeMetric(m))
+		}
+		close(done)
+	}()
+
+	var err error
+	if s, ok := c.collector.(scraper); ok {
+		err = s.collect(ctx, ch)
+	} else {
+		CollectContext(ctx, c.collector, ch)
+	}
+	close(ch)
+	<-done
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// The polled collector logs its own errors.
+	if err != nil {
+		c.failed = metrics
+		return
+	}
+	c.metrics = metrics
+	c.failed = nil
+	c.lastSuccess = c.now()
+}
+
+// frozenMetric is a copy of a metric taken at poll time. Collectors reuse
+// their gauges between polls, so the metrics they send must be copied.
+type frozenMetric struct {
+	desc   *prometheus.Desc
+	metric *dto.Metric
+}
+
+func freezeMetric(m prometheus.Metric) prometheus.Metric {
+	metric := &dto.Metric{}
+	if err := m.Write(metric); err != nil {
+		return prometheus.NewInvalidMetric(m.Desc(), err)
+	}
+	return &frozenMetric{desc: m.Desc(), metric: metric}
+}
+
+func (m *frozenMetric) Desc() *prometheus.Desc {
+	return m.desc
+}
+
+func (m *frozenMetric) Write(out *dto.Metric) error {
+	proto.Merge(out, m.metric)
+	return nil
+}
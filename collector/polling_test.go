@@ -0,0 +1,170 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/nginxinc/nginx-prometheus-exporter/client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type fakeScraper struct {
+	upMetric prometheus.Gauge
+	active   prometheus.Gauge
+	err      error
+}
+
+func (s *fakeScraper) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.upMetric.Desc()
+	ch <- s.active.Desc()
+}
+
+func (s *fakeScraper) upDesc() *prometheus.Desc {
+	return s.upMetric.Desc()
+}
+
+func (s *fakeScraper) Collect(ch chan<- prometheus.Metric) {
+	_ = s.collect(context.Background(), ch)
+}
+
+func (s *fakeScraper) collect(_ context.Context, ch chan<- prometheus.Metric) error {
+	if s.err != nil {
+		s.upMetric.Set(nginxDown)
+		ch <- s.upMetric
+		return s.err
+	}
+	s.upMetric.Set(nginxUp)
+	ch <- s.upMetric
+	ch <- s.active
+	return nil
+}
+
+func TestPollingCollector(t *testing.T) {
+	t.Parallel()
+
+	labels := map[string]string{"instance": "edge"}
+	inner := &fakeScraper{
+		upMetric: newUpMetric("nginx", labels),
+		active:   prometheus.NewGauge(prometheus.GaugeOpts{Name: "nginx_connections_active", Help: "Active client connections", ConstLabels: labels}),
+	}
+	inner.active.Set(7)
+
+	now := time.Unix(1700000000, 0)
+	c := NewPollingCollector(NewScrapeCollector(inner, "nginx", "oss", "http://edge/stub_status", labels), 10*time.Second, 30*time.Second, "nginx", labels, log.NewNopLogger())
+	c.now = func() time.Time { return now }
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	steps := []struct {
+		name          string
+		err           error
+		advance       time.Duration
+		wantUp        float64
+		wantActive    bool
+		wantTimestamp float64
+		wantErrors    float64
+	}{
+		{
+			name:       "first poll fails",
+			err:        errors.New("connection refused"),
+			wantUp:     nginxDown,
+			wantErrors: 1,
+		},
+		{
+			name:          "poll succeeds",
+			wantUp:        nginxUp,
+			wantActive:    true,
+			wantTimestamp: 1700000020,
+			wantErrors:    1,
+		},
+		{
+			name:          "failed poll keeps the metrics of the snapshot",
+			err:           errors.New("connection refused"),
+			wantUp:        nginxDown,
+			wantActive:    true,
+			wantTimestamp: 1700000020,
+			wantErrors:    2,
+		},
+		{
+			name:          "stale snapshot is dropped",
+			err:           errors.New("connection refused"),
+			advance:       30 * time.Second,
+			wantUp:        nginxDown,
+			wantTimestamp: 1700000020,
+			wantErrors:    3,
+		},
+	}
+	for _, step := range steps {
+		now = now.Add(10*time.Second + step.advance)
+		inner.err = step.err
+		c.poll(context.Background())
+
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("%s: Gather() returned error: %v", step.name, err)
+		}
+
+		if got, _ := metricValue(families, "nginx_up", nil); got != step.wantUp {
+			t.Errorf("%s: nginx_up = %v, want %v", step.name, got, step.wantUp)
+		}
+		if _, ok := metricValue(families, "nginx_connections_active", nil); ok != step.wantActive {
+			t.Errorf("%s: nginx_connections_active reported = %v, want %v", step.name, ok, step.wantActive)
+		}
+		if got, _ := metricValue(families, "nginx_exporter_last_successful_poll_timestamp_seconds", nil); got != step.wantTimestamp {
+			t.Errorf("%s: nginx_exporter_last_successful_poll_timestamp_seconds = %v, want %v", step.name, got, step.wantTimestamp)
+		}
+		if got, _ := metricValue(families, "nginx_exporter_scrape_errors_total", map[string]string{"reason": "read"}); got != step.wantErrors {
+			t.Errorf("%s: nginx_exporter_scrape_errors_total = %v, want %v", step.name, got, step.wantErrors)
+		}
+	}
+}
+
+func TestPollingCollectorAutoMode(t *testing.T) {
+	t.Parallel()
+
+	plus := &fakeScraper{
+		upMetric: newUpMetric("nginxplus", nil),
+		active:   prometheus.NewGauge(prometheus.GaugeOpts{Name: "nginxplus_connections_active", Help: "Active client connections"}),
+	}
+	detect := func(context.Context) (client.API, error) { return client.APIPlus, nil }
+	newCollector := func(client.API) (prometheus.Collector, error) { return plus, nil }
+	auto := NewNginxAutoCollector(detect, newCollector, "nginx", nil, log.NewNopLogger())
+
+	now := time.Unix(1700000000, 0)
+	c := NewPollingCollector(auto, 10*time.Second, 30*time.Second, "nginx", nil, log.NewNopLogger())
+	c.now = func() time.Time { return now }
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	steps := []struct {
+		name    string
+		err     error
+		advance time.Duration
+		wantUp  float64
+	}{
+		{name: "poll succeeds", wantUp: nginxUp},
+		{name: "poll fails", err: errors.New("connection refused"), wantUp: nginxDown},
+		{name: "stale snapshot is dropped", err: errors.New("connection refused"), advance: time.Minute, wantUp: nginxDown},
+	}
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		plus.err = step.err
+		c.poll(context.Background())
+
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("%s: Gather() returned error: %v", step.name, err)
+		}
+		if got, ok := metricValue(families, "nginx_up", nil); !ok || got != step.wantUp {
+			t.Errorf("%s: nginx_up = %v (reported %v), want %v", step.name, got, ok, step.wantUp)
+		}
+		if _, ok := metricValue(families, "nginxplus_up", nil); ok {
+			t.Errorf("%s: nginxplus_up reported, want only nginx_up", step.name)
+		}
+	}
+}
@@ -0,0 +1,404 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/procfs"
+)
+
+// reloadStartDelay is the delay after the start of the master after which
+// the workers are deemed started by a reload.
+const reloadStartDelay = 1.0
+
+// ProcessCollector collects the metrics of the NGINX master process and its
+// children from /proc. It implements prometheus.Collector interface.
+//
+// As a reload replaces all the workers of the master, the collector detects
+// reloads from the start times of the workers. The old workers finishing
+// their requests are told apart by their title. The metrics of the processes
+// are aggregated by type, so replaced workers do not leave series behind.
+type ProcessCollector struct {
+	logger      log.Logger
+	fs          procfs.FS
+	metrics     map[string]*prometheus.Desc
+	pidFile     string
+	processName string
+	// The state of the last collect to detect reloads.
+	masterPID    int
+	masterStart  float64
+	newestWorker float64
+	lastReload   float64
+	reloads      float64
+	// The CPU time of the processes by type, which keeps the time of the
+	// exited processes, and of every process at the last collect.
+	cpuTotals map[string]float64
+	cpuSeen   map[int]processCPU
+	mutex     sync.Mutex
+}
+
+// processCPU is the CPU time of a process at the last collect.
+type processCPU struct {
+	processType string
+	seconds     float64
+}
+
+// processTotals are the metrics of the processes of a type.
+type processTotals struct {
+	residentMemory float64
+	threads        float64
+	openFDs        float64
+	maxFDs         float64
+	hasOpenFDs     bool
+	hasMaxFDs      bool
+}
+
+// NewProcessCollector creates a ProcessCollector which reads the proc
+// filesystem mounted at procRoot. The master process is the one whose PID is
+// written in pidFile or, when pidFile is empty, the oldest process named
+// processName whose parent has another name.
+func NewProcessCollector(procRoot, pidFile, processName, namespace string, constLabels map[string]string, logger log.Logger) (*ProcessCollector, error) {
+	if pidFile == "" && processName == "" {
+		return nil, errors.New("either a pid file or a process name is required")
+	}
+	fs, err := procfs.NewFS(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	processLabels := []string{"type"}
+	return &ProcessCollector{
+		logger:      logger,
+		fs:          fs,
+		pidFile:     pidFile,
+		processName: processName,
+		cpuTotals:   make(map[string]float64),
+		cpuSeen:     make(map[int]processCPU),
+		metrics: map[string]*prometheus.Desc{
+			"up":                 newProcessMetric(namespace, "up", "Whether the NGINX master process is running", nil, constLabels),
+			"start_time_seconds": newProcessMetric(namespace, "master_start_time_seconds", "Start time of the NGINX master process since unix epoch in seconds", nil, constLabels),
+			"workers":            newProcessMetric(namespace, "workers", "Number of NGINX worker processes", nil, constLabels),
+			"old_workers":        newProcessMetric(namespace, "old_workers", "Number of NGINX worker processes of a previous configuration which are shutting down", nil, constLabels),
+			"reloads_total":      newConfigMetric(namespace, "reloads_total", "Configuration reloads detected from the replacement of the NGINX workers", nil, constLabels),
+			"last_reload":        newConfigMetric(namespace, "last_reload_timestamp_seconds", "Time the running configuration was loaded by a reload or the start of the NGINX master process since unix epoch in seconds", nil, constLabels),
+			"cpu_seconds_total":  newProcessMetric(namespace, "cpu_seconds_total", "User and system CPU time spent by the processes of the type in seconds", processLabels, constLabels),
+			"resident_memory":    newProcessMetric(namespace, "resident_memory_bytes", "Resident memory size of the processes of the type in bytes", processLabels, constLabels),
+			"open_fds":           newProcessMetric(namespace, "open_fds", "Highest number of open file descriptors of a process of the type", processLabels, constLabels),
+			"max_fds":            newProcessMetric(namespace, "max_fds", "Lowest maximum number of open file descriptors of a process of the type", processLabels, constLabels),
+			"threads":            newProcessMetric(namespace, "threads", "Number of threads of the processes of the type", processLabels, constLabels),
+		},
+	}, nil
+}
+
+func newProcessMetric(namespace, metricName, docString string, variableLabelNames []string, constLabels prometheus.Labels) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "process", metricName), docString, variableLabelNames, constLabels)
+}
+
+func newConfigMetric(namespace, metricName, docString string, variableLabelNames []string, constLabels prometheus.Labels) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "config", metricName), docString, variableLabelNames, constLabels)
+}
+
+// Describe sends the descriptors of the process metrics to the provided
+// channel.
+func (c *ProcessCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.metrics {
+		ch <- m
+	}
+}
+
+// Collect reads the process metrics from /proc and sends them to the
+// provided channel.
+func (c *ProcessCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	master, err := c.findMaster()
+	if err != nil {
+		level.Error(c.logger).Log("msg", "Error finding the NGINX master process", "error", err.Error())
+		ch <- prometheus.MustNewConstMetric(c.metrics["up"], prometheus.GaugeValue, nginxDown)
+		return
+	}
+	stat, err := master.Stat()
+	if err != nil {
+		level.Error(c.logger).Log("msg", "Error reading the NGINX master process", "pid", master.PID, "error", err.Error())
+		ch <- prometheus.MustNewConstMetric(c.metrics["up"], prometheus.GaugeValue, nginxDown)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.metrics["up"], prometheus.GaugeValue, nginxUp)
+	masterStart, err := stat.StartTime()
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "Error reading the start time of the NGINX master process", "error", err.Error())
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.metrics["start_time_seconds"], prometheus.GaugeValue, masterStart)
+	}
+	totals := make(map[string]*processTotals)
+	cpuSeen := make(map[int]processCPU)
+	c.addProcess(totals, cpuSeen, master, stat, "master")
+	defer c.collectTotals(ch, totals, cpuSeen)
+
+	children, err := c.children(master.PID)
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "Error listing the NGINX worker processes", "error", err.Error())
+		// The CPU time of the children is kept, so it is not counted
+		// twice by the next collect.
+		for pid, seen := range c.cpuSeen {
+			if _, ok := cpuSeen[pid]; !ok {
+				cpuSeen[pid] = seen
+			}
+		}
+		return
+	}
+	var workerStarts []float64
+	oldWorkers := 0
+	for _, child := range children {
+		// The process may have exited since it was listed.
+		stat, err := child.Stat()
+		if err != nil {
+			continue
+		}
+		processType := c.processType(child)
+		switch processType {
+		case "worker":
+			if start, err := stat.StartTime(); err == nil {
+				workerStarts = append(workerStarts, start)
+			}
+		case "old_worker":
+			oldWorkers++
+		}
+		c.addProcess(totals, cpuSeen, child, stat, processType)
+	}
+	ch <- prometheus.MustNewConstMetric(c.metrics["workers"], prometheus.GaugeValue, float64(len(workerStarts)))
+	ch <- prometheus.MustNewConstMetric(c.metrics["old_workers"], prometheus.GaugeValue, float64(oldWorkers))
+
+	c.trackReloads(master.PID, masterStart, workerStarts)
+	ch <- prometheus.MustNewConstMetric(c.metrics["reloads_total"], prometheus.CounterValue, c.reloads)
+	if c.lastReload > 0 {
+		ch <- prometheus.MustNewConstMetric(c.metrics["last_reload"], prometheus.GaugeValue, c.lastReload)
+	}
+}
+
+// LastReload returns the time the running configuration was loaded by a
+// reload or the start of the NGINX master process. It checks the workers
+// again, so that a reload since the last collect is detected.
+func (c *ProcessCollector) LastReload() (float64, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	master, err := c.findMaster()
+	if err != nil {
+		return 0, false
+	}
+	stat, err := master.Stat()
+	if err != nil {
+		return 0, false
+	}
+	masterStart, err := stat.StartTime()
+	if err != nil {
+		return 0, false
+	}
+	children, err := c.children(master.PID)
+	if err != nil {
+		return 0, false
+	}
+	var workerStarts []float64
+	for _, child := range children {
+		if c.processType(child) != "worker" {
+			continue
+		}
+		if stat, err := child.Stat(); err == nil {
+			if start, err := stat.StartTime(); err == nil {
+				workerStarts = append(workerStarts, start)
+			}
+		}
+	}
+	c.trackReloads(master.PID, masterStart, workerStarts)
+	return c.lastReload, c.lastReload > 0
+}
+
+// Workers returns the number of running worker processes of the NGINX
+// master, without the old workers shutting down after a reload.
+func (c *ProcessCollector) Workers() (float64, bool) {
+	master, err := c.findMaster()
+	if err != nil {
+		return 0, false
+	}
+	children, err := c.children(master.PID)
+	if err != nil {
+		return 0, false
+	}
+	workers := 0
+	for _, child := range children {
+		if c.processType(child) == "worker" {
+			workers++
+		}
+	}
+	return float64(workers), true
+}
+
+// trackReloads detects a reload when the oldest worker started after the
+// newest worker of the last collect, as a reload replaces all the workers
+// while a crashed worker is replaced alone. The first collect after the
+// master started only checks whether the workers started well after the
+// master.
+func (c *ProcessCollector) trackReloads(masterPID int, masterStart float64, workerStarts []float64) {
+	if len(workerStarts) == 0 {
+		return
+	}
+	oldest, newest := slices.Min(workerStarts), slices.Max(workerStarts)
+
+	if masterPID != c.masterPID || masterStart != c.masterStart {
+		c.masterPID = masterPID
+		c.masterStart = masterStart
+		c.newestWorker = newest
+		c.lastReload = masterStart
+		if oldest-masterStart > reloadStartDelay {
+			c.lastReload = oldest
+		}
+		return
+	}
+	if oldest > c.newestWorker {
+		c.reloads++
+		c.lastReload = oldest
+	}
+	c.newestWorker = newest
+}
+
+// addProcess adds the metrics of a single process to the totals of its type.
+// The CPU time the process spent since the last collect is added to the CPU
+// time of its type, as the type of a worker changes when it shuts down.
+func (c *ProcessCollector) addProcess(totals map[string]*processTotals, cpuSeen map[int]processCPU, p procfs.Proc, stat procfs.ProcStat, processType string) {
+	t, ok := totals[processType]
+	if !ok {
+		t = &processTotals{}
+		totals[processType] = t
+	}
+	t.residentMemory += float64(stat.ResidentMemory())
+	t.threads += float64(stat.NumThreads)
+	if fds, err := p.FileDescriptorsLen(); err == nil {
+		t.openFDs = max(t.openFDs, float64(fds))
+		t.hasOpenFDs = true
+	}
+	if limits, err := p.Limits(); err == nil {
+		if !t.hasMaxFDs || float64(limits.OpenFiles) < t.maxFDs {
+			t.maxFDs = float64(limits.OpenFiles)
+		}
+		t.hasMaxFDs = true
+	}
+
+	seconds := stat.CPUTime()
+	spent := seconds
+	// A lower CPU time means the PID was reused by a new process.
+	if seen, ok := c.cpuSeen[p.PID]; ok && seconds >= seen.seconds {
+		spent = seconds - seen.seconds
+	}
+	c.cpuTotals[processType] += spent
+	cpuSeen[p.PID] = processCPU{processType: processType, seconds: seconds}
+}
+
+// collectTotals sends the metrics of the processes by type, and keeps the CPU
+// time of the processes seen for the next collect.
+func (c *ProcessCollector) collectTotals(ch chan<- prometheus.Metric, totals map[string]*processTotals, cpuSeen map[int]processCPU) {
+	c.cpuSeen = cpuSeen
+	for processType, seconds := range c.cpuTotals {
+		ch <- prometheus.MustNewConstMetric(c.metrics["cpu_seconds_total"], prometheus.CounterValue, seconds, processType)
+	}
+	for processType, t := range totals {
+		ch <- prometheus.MustNewConstMetric(c.metrics["resident_memory"], prometheus.GaugeValue, t.residentMemory, processType)
+		ch <- prometheus.MustNewConstMetric(c.metrics["threads"], prometheus.GaugeValue, t.threads, processType)
+		if t.hasOpenFDs {
+			ch <- prometheus.MustNewConstMetric(c.metrics["open_fds"], prometheus.GaugeValue, t.openFDs, processType)
+		}
+		if t.hasMaxFDs {
+			ch <- prometheus.MustNewConstMetric(c.metrics["max_fds"], prometheus.GaugeValue, t.maxFDs, processType)
+		}
+	}
+}
+
+// findMaster returns the NGINX master process.
+func (c *ProcessCollector) findMaster() (procfs.Proc, error) {
+	if c.pidFile != "" {
+		content, err := os.ReadFile(c.pidFile)
+		if err != nil {
+			return procfs.Proc{}, err
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+		if err != nil {
+			return procfs.Proc{}, fmt.Errorf("invalid pid file %s: %w", c.pidFile, err)
+		}
+		return c.fs.Proc(pid)
+	}
+
+	procs, err := c.fs.AllProcs()
+	if err != nil {
+		return procfs.Proc{}, err
+	}
+	comms := make(map[int]string, len(procs))
+	for _, p := range procs {
+		if comm, err := p.Comm(); err == nil {
+			comms[p.PID] = comm
+		}
+	}
+	// AllProcs sorts the processes by PID, so the first master is the
+	// oldest unless the PIDs wrapped around.
+	for _, p := range procs {
+		if comms[p.PID] != c.processName {
+			continue
+		}
+		stat, err := p.Stat()
+		if err != nil {
+			continue
+		}
+		if comms[stat.PPID] != c.processName {
+			return p, nil
+		}
+	}
+	return procfs.Proc{}, fmt.Errorf("no process named %q", c.processName)
+}
+
+// children returns the child processes of the process with the given PID.
+func (c *ProcessCollector) children(pid int) ([]procfs.Proc, error) {
+	procs, err := c.fs.AllProcs()
+	if err != nil {
+		return nil, err
+	}
+	var children []procfs.Proc
+	for _, p := range procs {
+		stat, err := p.Stat()
+		if err != nil {
+			continue
+		}
+		if stat.PPID == pid {
+			children = append(children, p)
+		}
+	}
+	return children, nil
+}
+
+// processType returns the type of a child of the master from its title, such
+// as "nginx: worker process".
+func (c *ProcessCollector) processType(p procfs.Proc) string {
+	cmdline, err := p.CmdLine()
+	if err != nil {
+		return "other"
+	}
+	title := strings.Join(cmdline, " ")
+	switch {
+	case strings.Contains(title, "worker process is shutting down"):
+		return "old_worker"
+	case strings.Contains(title, "worker process"):
+		return "worker"
+	case strings.Contains(title, "cache manager process"):
+		return "cache_manager"
+	case strings.Contains(title, "cache loader process"):
+		return "cache_loader"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,218 @@
+package collector
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestProcessCollector(t *testing.T) {
+	t.Parallel()
+
+	pageSize := float64(os.Getpagesize())
+	master := map[string]string{"type": "master"}
+	worker := map[string]string{"type": "worker"}
+	cacheManager := map[string]string{"type": "cache_manager"}
+	wantValues := []struct {
+		labels map[string]string
+		name   string
+		want   float64
+	}{
+		{name: "nginx_process_up", want: 1},
+		{name: "nginx_process_master_start_time_seconds", want: 1700000500},
+		{name: "nginx_process_workers", want: 2},
+		{name: "nginx_process_old_workers", want: 1},
+		{name: "nginx_config_reloads_total", want: 0},
+		{name: "nginx_config_last_reload_timestamp_seconds", want: 1700000500},
+		{name: "nginx_process_cpu_seconds_total", labels: master, want: 2},
+		{name: "nginx_process_cpu_seconds_total", labels: worker, want: 9},
+		{name: "nginx_process_resident_memory_bytes", labels: worker, want: 4900 * pageSize},
+		{name: "nginx_process_open_fds", labels: master, want: 3},
+		{name: "nginx_process_open_fds", labels: worker, want: 5},
+		{name: "nginx_process_max_fds", labels: worker, want: 1024},
+		{name: "nginx_process_threads", labels: worker, want: 3},
+		{name: "nginx_process_threads", labels: cacheManager, want: 1},
+		{name: "nginx_process_resident_memory_bytes", labels: map[string]string{"type": "old_worker"}, want: 9000 * pageSize},
+	}
+
+	tests := []struct {
+		name        string
+		pidFile     string
+		processName string
+	}{
+		{name: "pid file", pidFile: "testdata/nginx.pid"},
+		{name: "process name", processName: "nginx"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := NewProcessCollector("testdata/proc", tt.pidFile, tt.processName, "nginx", nil, log.NewNopLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(c)
+			families, err := registry.Gather()
+			if err != nil {
+				t.Fatalf("Gather() returned error: %v", err)
+			}
+
+			for _, w := range wantValues {
+				got, ok := metricValue(families, w.name, w.labels)
+				if !ok {
+					t.Errorf("%s%v is missing", w.name, w.labels)
+					continue
+				}
+				if got != w.want {
+					t.Errorf("%s%v = %v, want %v", w.name, w.labels, got, w.want)
+				}
+			}
+			if got, ok := c.Workers(); !ok || got != 2 {
+				t.Errorf("Workers() = %v, %v, want 2, true", got, ok)
+			}
+		})
+	}
+}
+
+func TestProcessCollectorDown(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewProcessCollector("testdata/proc", "", "openresty", "nginx", nil, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+	if got, ok := metricValue(families, "nginx_process_up", nil); !ok || got != 0 {
+		t.Errorf("nginx_process_up = %v, want 0", got)
+	}
+	if _, ok := metricValue(families, "nginx_process_workers", nil); ok {
+		t.Errorf("nginx_process_workers is reported without a master process")
+	}
+}
+
+func TestProcessCollectorReloads(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "stat"), []byte("btime 1700000000\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// The start times are in clock ticks since boot, 100 per second.
+	writeProcess(t, root, 100, 1, "nginx: master process nginx", 1000)
+	writeProcess(t, root, 101, 100, "nginx: worker process", 1001)
+	writeProcess(t, root, 102, 100, "nginx: worker process", 1001)
+
+	c, err := NewProcessCollector(root, "", "nginx", "nginx", nil, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	steps := []struct {
+		update     func()
+		name       string
+		reloads    float64
+		lastReload float64
+		oldWorkers float64
+		workerCPU  float64
+	}{
+		{
+			name:       "start",
+			update:     func() {},
+			lastReload: 1700000010,
+			workerCPU:  0.4,
+		},
+		{
+			name: "crashed worker",
+			update: func() {
+				os.RemoveAll(filepath.Join(root, "102"))
+				writeProcess(t, root, 103, 100, "nginx: worker process", 5000)
+			},
+			lastReload: 1700000010,
+			workerCPU:  0.6,
+		},
+		{
+			name: "reload",
+			update: func() {
+				writeProcess(t, root, 101, 100, "nginx: worker process is shutting down", 1001)
+				writeProcess(t, root, 103, 100, "nginx: worker process is shutting down", 5000)
+				writeProcess(t, root, 104, 100, "nginx: worker process", 9000)
+				writeProcess(t, root, 105, 100, "nginx: worker process", 9000)
+			},
+			reloads:    1,
+			lastReload: 1700000090,
+			oldWorkers: 2,
+			workerCPU:  1,
+		},
+		{
+			name: "old workers exited",
+			update: func() {
+				os.RemoveAll(filepath.Join(root, "101"))
+				os.RemoveAll(filepath.Join(root, "103"))
+			},
+			reloads:    1,
+			lastReload: 1700000090,
+			workerCPU:  1,
+		},
+	}
+	for _, step := range steps {
+		step.update()
+		// LastReload detects the reload before the collect, which must not
+		// count it again.
+		if got, ok := c.LastReload(); !ok || got != step.lastReload {
+			t.Errorf("%s: LastReload() = %v, %v, want %v, true", step.name, got, ok, step.lastReload)
+		}
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("%s: Gather() returned error: %v", step.name, err)
+		}
+		for name, want := range map[string]float64{
+			"nginx_config_reloads_total":                 step.reloads,
+			"nginx_config_last_reload_timestamp_seconds": step.lastReload,
+			"nginx_process_old_workers":                  step.oldWorkers,
+		} {
+			if got, ok := metricValue(families, name, nil); !ok || got != want {
+				t.Errorf("%s: %s = %v, want %v", step.name, name, got, want)
+			}
+		}
+		// The CPU time of the replaced workers stays counted.
+		got, ok := metricValue(families, "nginx_process_cpu_seconds_total", map[string]string{"type": "worker"})
+		if !ok || math.Abs(got-step.workerCPU) > 1e-9 {
+			t.Errorf("%s: nginx_process_cpu_seconds_total{type=\"worker\"} = %v, want %v", step.name, got, step.workerCPU)
+		}
+	}
+}
+
+// writeProcess writes the files of a process to a proc filesystem at root.
+func writeProcess(t *testing.T, root string, pid, ppid int, title string, startTime int) {
+	t.Helper()
+
+	dir := filepath.Join(root, fmt.Sprint(pid))
+	if err := os.MkdirAll(filepath.Join(dir, "fd"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"comm":    "nginx\n",
+		"cmdline": title + "\x00",
+		"stat": fmt.Sprintf("%d (nginx) S %d %d %d 0 -1 4194560 100 0 0 0 10 10 0 0 20 0 1 0 %d 10000000 1000 "+
+			"18446744073709551615 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0\n", pid, ppid, ppid, ppid, startTime),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package collector
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/nginxinc/nginx-prometheus-exporter/client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ReqStatCollector collects the request statistics of the Tengine
+// ngx_http_reqstat_module. It implements prometheus.Collector interface.
+type ReqStatCollector struct {
+	upMetric      prometheus.Gauge
+	logger        log.Logger
+	reqStatClient *client.ReqStatClient
+	metrics       map[string]*prometheus.Desc
+	mutex         sync.Mutex
+}
+
+// NewReqStatCollector creates a ReqStatCollector.
+func NewReqStatCollector(reqStatClient *client.ReqStatClient, namespace string, constLabels map[string]string, logger log.Logger) *ReqStatCollector {
+	return &ReqStatCollector{
+		reqStatClient: reqStatClient,
+		logger:        logger,
+		metrics: map[string]*prometheus.Desc{
+			"received":                    newReqStatMetric(namespace, "received", "Bytes received from clients", nil, constLabels),
+			"sent":                        newReqStatMetric(namespace, "sent", "Bytes sent to clients", nil, constLabels),
+			"connections":                 newReqStatMetric(namespace, "connections", "Total client connections", nil, constLabels),
+			"requests":                    newReqStatMetric(namespace, "requests", "Total client requests", nil, constLabels),
+			"responses":                   newReqStatMetric(namespace, "responses", "Total responses sent to clients", []string{"code"}, constLabels),
+			"responses_codes":             newReqStatMetric(namespace, "responses_codes", "Total responses sent to clients by code", []string{"code"}, constLabels),
+			"request_time_seconds_total":  newReqStatMetric(namespace, "request_time_seconds_total", "Total time spent processing requests", nil, constLabels),
+			"upstream_requests":           newReqStatMetric(namespace, "upstream_requests", "Total requests sent to upstream servers", nil, constLabels),
+			"upstream_tries":              newReqStatMetric(namespace, "upstream_tries", "Total attempts to send requests to upstream servers", nil, constLabels),
+			"upstream_responses":          newReqStatMetric(namespace, "upstream_responses", "Total error responses received from upstream servers", []string{"code"}, constLabels),
+			"upstream_time_seconds_total": newReqStatMetric(namespace, "upstream_time_seconds_total", "Total time spent waiting for upstream responses", nil, constLabels),
+		},
+		upMetric: newUpMetric(namespace, constLabels),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of the reqstat
+// metrics to the provided channel.
+func (c *ReqStatCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.upMetric.Desc()
+
+	for _, m := range c.metrics {
+		ch <- m
+	}
+}
+
+// Collect fetches the request statistics and sends them to the provided
+// channel.
+func (c *ReqStatCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+// CollectContext is like Collect but cancels the request to Tengine when ctx is done.
+func (c *ReqStatCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	_ = c.collect(ctx, ch)
+}
+
+func (c *ReqStatCollector) collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	c.mutex.Lock() // To protect metrics from concurrent collects
+	defer c.mutex.Unlock()
+
+	stats, err := c.reqStatClient.GetStats(ctx)
+	if err != nil {
+		c.upMetric.Set(nginxDown)
+		ch <- c.upMetric
+		level.Warn(c.logger).Log("msg", "Error getting stats", "error", err.Error())
+		return err
+	}
+
+	c.upMetric.Set(nginxUp)
+	ch <- c.upMetric
+
+	for _, s := range stats {
+		ch <- prometheus.MustNewConstMetric(c.metrics["received"],
+			prometheus.CounterValue, float64(s.BytesIn), s.Key)
+		ch <- prometheus.MustNewConstMetric(c.metrics["sent"],
+			prometheus.CounterValue, float64(s.BytesOut), s.Key)
+		ch <- prometheus.MustNewConstMetric(c.metrics["connections"],
+			prometheus.CounterValue, float64(s.Connections), s.Key)
+		ch <- prometheus.MustNewConstMetric(c.metrics["requests"],
+			prometheus.CounterValue, float64(s.Requests), s.Key)
+		ch <- prometheus.MustNewConstMetric(c.metrics["request_time_seconds_total"],
+			prometheus.CounterValue, float64(s.RequestTime)/1000, s.Key)
+		ch <- prometheus.MustNewConstMetric(c.metrics["upstream_requests"],
+			prometheus.CounterValue, float64(s.UpstreamRequests), s.Key)
+		ch <- prometheus.MustNewConstMetric(c.metrics["upstream_tries"],
+			prometheus.CounterValue, float64(s.UpstreamTries), s.Key)
+		ch <- prometheus.MustNewConstMetric(c.metrics["upstream_time_seconds_total"],
+			prometheus.CounterValue, float64(s.UpstreamTime)/1000, s.Key)
+		for code, count := range s.Responses {
+			ch <- prometheus.MustNewConstMetric(c.metrics["responses"],
+				prometheus.CounterValue, float64(count), s.Key, code)
+		}
+		for code, count := range s.ResponseCodes {
+			ch <- prometheus.MustNewConstMetric(c.metrics["responses_codes"],
+				prometheus.CounterValue, float64(count), s.Key, code)
+		}
+		for code, count := range s.UpstreamResponses {
+			ch <- prometheus.MustNewConstMetric(c.metrics["upstream_responses"],
+				prometheus.CounterValue, float64(count), s.Key, code)
+		}
+	}
+
+	return nil
+}
+
+func newReqStatMetric(namespace string, metricName string, docString string, variableLabelNames []string, constLabels prometheus.Labels) *prometheus.Desc {
+	labels := []string{"key"}
+	labels = append(labels, variableLabelNames...)
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "reqstat", metricName), docString, labels, constLabels)
+}
@@ -0,0 +1,58 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/nginxinc/nginx-prometheus-exporter/client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestReqStatCollector(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.FileServer(http.Dir("../client/testdata/reqstat")))
+	defer srv.Close()
+
+	c := NewReqStatCollector(client.NewReqStatClient(srv.Client(), srv.URL+"/reqstat.txt"), "nginx", nil, log.NewNopLogger())
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+
+	key := "api.example.com,10.0.0.10:443"
+	tests := []struct {
+		labels map[string]string
+		name   string
+		want   float64
+	}{
+		{name: "nginx_up", want: 1},
+		{name: "nginx_reqstat_received", labels: map[string]string{"key": key}, want: 48213},
+		{name: "nginx_reqstat_sent", labels: map[string]string{"key": key}, want: 960121},
+		{name: "nginx_reqstat_connections", labels: map[string]string{"key": key}, want: 120},
+		{name: "nginx_reqstat_requests", labels: map[string]string{"key": key}, want: 310},
+		{name: "nginx_reqstat_responses", labels: map[string]string{"key": key, "code": "4xx"}, want: 20},
+		{name: "nginx_reqstat_responses", labels: map[string]string{"key": key, "code": "other"}, want: 1},
+		{name: "nginx_reqstat_responses_codes", labels: map[string]string{"key": key, "code": "404"}, want: 15},
+		{name: "nginx_reqstat_request_time_seconds_total", labels: map[string]string{"key": key}, want: 9.3},
+		{name: "nginx_reqstat_upstream_requests", labels: map[string]string{"key": key}, want: 300},
+		{name: "nginx_reqstat_upstream_time_seconds_total", labels: map[string]string{"key": key}, want: 9.1},
+		{name: "nginx_reqstat_upstream_tries", labels: map[string]string{"key": key}, want: 302},
+		{name: "nginx_reqstat_upstream_responses", labels: map[string]string{"key": key, "code": "5xx"}, want: 3},
+	}
+	for _, tt := range tests {
+		got, ok := metricValue(families, tt.name, tt.labels)
+		if !ok {
+			t.Errorf("%s%v is missing", tt.name, tt.labels)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s%v = %v, want %v", tt.name, tt.labels, got, tt.want)
+		}
+	}
+}
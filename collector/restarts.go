@@ -0,0 +1,78 @@
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nginxinc/nginx-prometheus-exporter/client"
+)
+
+// restartStateMaxAge is the time after which the restart state of a target
+// which is no longer scraped is dropped, so probes of many targets do not
+// accumulate state.
+const restartStateMaxAge = time.Hour
+
+// RestartTracker detects the restarts of NGINX from the counters of the
+// stub_status page going backwards between the scrapes of a target. It keeps
+// the state of every target by its scrape URI, so the state outlives the
+// collectors, which are created for every probe and for the targets changed
+// by a reload.
+type RestartTracker struct {
+	now     func() time.Time
+	targets map[string]*restartState
+	mutex   sync.Mutex
+}
+
+type restartState struct {
+	lastSeen    time.Time
+	previous    *client.StubStats
+	restarts    float64
+	lastRestart float64
+}
+
+// NewRestartTracker creates a RestartTracker without any state.
+func NewRestartTracker() *RestartTracker {
+	return &RestartTracker{
+		now:     time.Now,
+		targets: make(map[string]*restartState),
+	}
+}
+
+// observe records the stats of a successful scrape of the target and returns
+// the number of restarts detected for it and the time of the last one, 0
+// without restarts. The restarted result reports whether the stats show a
+// new restart.
+func (t *RestartTracker) observe(target string, stats *client.StubStats) (restarts, lastRestart float64, restarted bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := t.now()
+	for name, s := range t.targets {
+		if now.Sub(s.lastSeen) > restartStateMaxAge {
+			delete(t.targets, name)
+		}
+	}
+
+	s, ok := t.targets[target]
+	if !ok {
+		s = &restartState{}
+		t.targets[target] = s
+	}
+	if s.previous != nil && isReset(s.previous, stats) {
+		s.restarts++
+		s.lastRestart = float64(now.UnixNano()) / 1e9
+		restarted = true
+	}
+	s.previous = stats
+	s.lastSeen = now
+	return s.restarts, s.lastRestart, restarted
+}
+
+// isReset reports whether any counter of current is lower than in previous,
+// which only happens when NGINX restarted between the scrapes. A reload keeps
+// the counters.
+func isReset(previous, current *client.StubStats) bool {
+	return current.Connections.Accepted < previous.Connections.Accepted ||
+		current.Connections.Handled < previous.Connections.Handled ||
+		current.Requests < previous.Requests
+}
@@ -0,0 +1,33 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nginxinc/nginx-prometheus-exporter/client"
+)
+
+func TestRestartTrackerDropsOldTargets(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1700000000, 0)
+	tracker := NewRestartTracker()
+	tracker.now = func() time.Time { return now }
+
+	before := &client.StubStats{Requests: 300}
+	after := &client.StubStats{Requests: 20}
+
+	tracker.observe("http://edge/stub_status", before)
+	if restarts, _, _ := tracker.observe("http://edge/stub_status", after); restarts != 1 {
+		t.Fatalf("observe() restarts = %v, want 1", restarts)
+	}
+
+	now = now.Add(restartStateMaxAge + time.Second)
+	tracker.observe("http://api/stub_status", before)
+	if _, ok := tracker.targets["http://edge/stub_status"]; ok {
+		t.Errorf("observe() kept the state of a target not scraped for %v", restartStateMaxAge)
+	}
+	if restarts, _, restarted := tracker.observe("http://edge/stub_status", before); restarts != 0 || restarted {
+		t.Errorf("observe() = %v restarts, restarted %v, want the state of a new target", restarts, restarted)
+	}
+}
@@ -0,0 +1,156 @@
+package collector
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nginxinc/nginx-prometheus-exporter/client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var scrapeErrorReasons = []string{
+	client.ReasonDial,
+	client.ReasonTLS,
+	client.ReasonTimeout,
+	client.ReasonHTTPStatus,
+	client.ReasonRead,
+	client.ReasonParse,
+}
+
+// responseReporter is implemented by the collectors which keep the response
+// of their last successful scrape.
+type responseReporter interface {
+	responseInfo() *client.ResponseInfo
+}
+
+// ScrapeCollector reports the duration and the outcome of the scrapes of a
+// collector next to its metrics, and the target it scrapes. The errors of
+// collectors which do not report them cannot be counted. It implements
+// prometheus.Collector interface.
+type ScrapeCollector struct {
+	collector   prometheus.Collector
+	duration    prometheus.Histogram
+	errors      *prometheus.CounterVec
+	lastSuccess prometheus.Gauge
+	targetInfo  *prometheus.Desc
+	mode        string
+	scrapeURI   string
+}
+
+// NewScrapeCollector creates a ScrapeCollector for c, which scrapes the target
+// at scrapeURI in the configured mode, such as auto. The namespace is the one
+// of the target_info metric, and the const labels tell the targets apart.
+func NewScrapeCollector(c prometheus.Collector, namespace, mode, scrapeURI string, constLabels map[string]string) *ScrapeCollector {
+	sc := &ScrapeCollector{
+		collector: c,
+		mode:      mode,
+		scrapeURI: redactURI(scrapeURI),
+		targetInfo: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "target_info"),
+			"Mode, scrape URI, Server header, remote address and TLS connection of the last successful response of the target",
+			[]string{"mode", "scrape_uri", "server", "version", "remote_addr", "tls_version", "tls_cipher"}, constLabels),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   exporterNamespace,
+			Name:        "scrape_duration_seconds",
+			Help:        "Duration of the scrapes of the target",
+			ConstLabels: constLabels,
+			Buckets:     prometheus.DefBuckets,
+		}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   exporterNamespace,
+			Name:        "scrape_errors_total",
+			Help:        "Failed scrapes of the target by reason: dial, tls, timeout, http_status, read or parse",
+			ConstLabels: constLabels,
+		}, []string{"reason"}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   exporterNamespace,
+			Name:        "last_successful_scrape_timestamp_seconds",
+			Help:        "Timestamp of the last successful scrape of the target",
+			ConstLabels: constLabels,
+		}),
+	}
+	for _, reason := range scrapeErrorReasons {
+		sc.errors.WithLabelValues(reason)
+	}
+	return sc
+}
+
+// Describe sends the descriptors of the collector and of the scrape metrics to
+// the provided channel. When the collector is unchecked, so is the ScrapeCollector.
+func (c *ScrapeCollector) Describe(ch chan<- *prometheus.Desc) {
+	if describeCollector(c.collector, ch) {
+		c.duration.Describe(ch)
+		c.errors.Describe(ch)
+		c.lastSuccess.Describe(ch)
+		ch <- c.targetInfo
+	}
+}
+
+// Collect scrapes the collector and sends its metrics and the scrape metrics
+// to the provided channel.
+func (c *ScrapeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+// CollectContext is like Collect but passes ctx to the collector.
+func (c *ScrapeCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	_ = c.collect(ctx, ch)
+}
+
+func (c *ScrapeCollector) collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	start := time.Now()
+	var err error
+	if s, ok := c.collector.(scraper); ok {
+		err = s.collect(ctx, ch)
+	} else {
+		CollectContext(ctx, c.collector, ch)
+	}
+	c.duration.Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		c.errors.WithLabelValues(client.ErrorReason(err)).Inc()
+	} else {
+		c.lastSuccess.SetToCurrentTime()
+	}
+
+	c.duration.Collect(ch)
+	c.errors.Collect(ch)
+	c.lastSuccess.Collect(ch)
+
+	// Only some clients capture the response, the labels of the others are
+	// empty.
+	var r client.ResponseInfo
+	if rr, ok := c.collector.(responseReporter); ok {
+		if info := rr.responseInfo(); info != nil {
+			r = *info
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(c.targetInfo, prometheus.GaugeValue, 1,
+		c.mode, c.scrapeURI, r.Server, serverVersion(r.Server), r.RemoteAddr, r.TLSVersion, r.TLSCipher)
+	return err
+}
+
+// redactURI replaces the password of a URI with "xxxxx". URIs without a
+// password, such as unix domain socket addresses, are returned unchanged.
+func redactURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.User == nil {
+		return uri
+	}
+	if _, ok := u.User.Password(); !ok {
+		return uri
+	}
+	return u.Redacted()
+}
+
+// serverVersion returns the version of a Server header, such as "1.25.3" for
+// "nginx/1.25.3 (Ubuntu)", or an empty string with server_tokens off.
+func serverVersion(server string) string {
+	_, version, ok := strings.Cut(server, "/")
+	if !ok {
+		return ""
+	}
+	version, _, _ = strings.Cut(version, " ")
+	return version
+}
@@ -0,0 +1,292 @@
+package collector
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/nginxinc/nginx-prometheus-exporter/client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// VTSCollector collects metrics from the JSON status of the nginx-module-vts
+// and nginx-module-sts modules. The metrics are named like those of
+// NginxPlusCollector. It implements prometheus.Collector interface.
+type VTSCollector struct {
+	upMetric                    prometheus.Gauge
+	logger                      log.Logger
+	vtsClient                   *client.VTSClient
+	totalMetrics                map[string]*prometheus.Desc
+	serverZoneMetrics           map[string]*prometheus.Desc
+	filterZoneMetrics           map[string]*prometheus.Desc
+	upstreamServerMetrics       map[string]*prometheus.Desc
+	cacheZoneMetrics            map[string]*prometheus.Desc
+	streamServerZoneMetrics     map[string]*prometheus.Desc
+	streamFilterZoneMetrics     map[string]*prometheus.Desc
+	streamUpstreamServerMetrics map[string]*prometheus.Desc
+	mutex                       sync.Mutex
+}
+
+// NewVTSCollector creates a VTSCollector.
+func NewVTSCollector(vtsClient *client.VTSClient, namespace string, constLabels map[string]string, logger log.Logger) *VTSCollector {
+	return &VTSCollector{
+		vtsClient: vtsClient,
+		logger:    logger,
+		totalMetrics: map[string]*prometheus.Desc{
+			"connections_active":   newGlobalMetric(namespace, "connections_active", "Active client connections", constLabels),
+			"connections_accepted": newGlobalMetric(namespace, "connections_accepted", "Accepted client connections", constLabels),
+			"connections_handled":  newGlobalMetric(namespace, "connections_handled", "Handled client connections", constLabels),
+			"connections_reading":  newGlobalMetric(namespace, "connections_reading", "Connections where NGINX is reading the request header", constLabels),
+			"connections_writing":  newGlobalMetric(namespace, "connections_writing", "Connections where NGINX is writing the response back to the client", constLabels),
+			"connections_waiting":  newGlobalMetric(namespace, "connections_waiting", "Idle client connections", constLabels),
+			"http_requests_total":  newGlobalMetric(namespace, "http_requests_total", "Total http requests", constLabels),
+		},
+		serverZoneMetrics: map[string]*prometheus.Desc{
+			"requests":     newServerZoneMetric(namespace, "requests", "Total client requests", nil, constLabels),
+			"responses":    newServerZoneMetric(namespace, "responses", "Total responses sent to clients", []string{"code"}, constLabels),
+			"received":     newServerZoneMetric(namespace, "received", "Bytes received from clients", nil, constLabels),
+			"sent":         newServerZoneMetric(namespace, "sent", "Bytes sent to clients", nil, constLabels),
+			"request_time": newServerZoneMetric(namespace, "request_time", "Average request processing time in milliseconds", nil, constLabels),
+		},
+		filterZoneMetrics: map[string]*prometheus.Desc{
+			"requests":     newFilterZoneMetric(namespace, "filter_zone", "requests", "Total client requests", nil, constLabels),
+			"responses":    newFilterZoneMetric(namespace, "filter_zone", "responses", "Total responses sent to clients", []string{"code"}, constLabels),
+			"received":     newFilterZoneMetric(namespace, "filter_zone", "received", "Bytes received from clients", nil, constLabels),
+			"sent":         newFilterZoneMetric(namespace, "filter_zone", "sent", "Bytes sent to clients", nil, constLabels),
+			"request_time": newFilterZoneMetric(namespace, "filter_zone", "request_time", "Average request processing time in milliseconds", nil, constLabels),
+		},
+		upstreamServerMetrics: map[string]*prometheus.Desc{
+			"state":         newUpstreamServerMetric(namespace, "state", "Current state", nil, constLabels),
+			"requests":      newUpstreamServerMetric(namespace, "requests", "Total client requests", nil, constLabels),
+			"responses":     newUpstreamServerMetric(namespace, "responses", "Total responses sent to clients", []string{"code"}, constLabels),
+			"sent":          newUpstreamServerMetric(namespace, "sent", "Bytes sent to this server", nil, constLabels),
+			"received":      newUpstreamServerMetric(namespace, "received", "Bytes received from this server", nil, constLabels),
+			"response_time": newUpstreamServerMetric(namespace, "response_time", "Average time to get the full response from the server", nil, constLabels),
+		},
+		cacheZoneMetrics: map[string]*prometheus.Desc{
+			"size":                  newCacheZoneMetric(namespace, "size", "Total size of the cache", constLabels),
+			"max_size":              newCacheZoneMetric(namespace, "max_size", "Maximum size of the cache", constLabels),
+			"received":              newCacheZoneMetric(namespace, "received", "Bytes written to the cache", constLabels),
+			"sent":                  newCacheZoneMetric(namespace, "sent", "Bytes read from the cache", constLabels),
+			"hit_responses":         newCacheZoneMetric(namespace, "hit_responses", "Total number of cache hits", constLabels),
+			"stale_responses":       newCacheZoneMetric(namespace, "stale_responses", "Total number of stale cache hits", constLabels),
+			"updating_responses":    newCacheZoneMetric(namespace, "updating_responses", "Total number of cache hits while cache is updating", constLabels),
+			"revalidated_responses": newCacheZoneMetric(namespace, "revalidated_responses", "Total number of cache revalidations", constLabels),
+			"miss_responses":        newCacheZoneMetric(namespace, "miss_responses", "Total number of cache misses", constLabels),
+			"expired_responses":     newCacheZoneMetric(namespace, "expired_responses", "Total number of cache hits with expired TTL", constLabels),
+			"bypass_responses":      newCacheZoneMetric(namespace, "bypass_responses", "Total number of cache bypasses", constLabels),
+			"scarce_responses":      newCacheZoneMetric(namespace, "scarce_responses", "Total number of responses not cached because they were requested too rarely", constLabels),
+		},
+		streamServerZoneMetrics: map[string]*prometheus.Desc{
+			"connections": newStreamServerZoneMetric(namespace, "connections", "Total connections", nil, constLabels),
+			"sessions":    newStreamServerZoneMetric(namespace, "sessions", "Total sessions completed", []string{"code"}, constLabels),
+			"received":    newStreamServerZoneMetric(namespace, "received", "Bytes received from clients", nil, constLabels),
+			"sent":        newStreamServerZoneMetric(namespace, "sent", "Bytes sent to clients", nil, constLabels),
+		},
+		streamFilterZoneMetrics: map[string]*prometheus.Desc{
+			"connections": newFilterZoneMetric(namespace, "stream_filter_zone", "connections", "Total connections", nil, constLabels),
+			"sessions":    newFilterZoneMetric(namespace, "stream_filter_zone", "sessions", "Total sessions completed", []string{"code"}, constLabels),
+			"received":    newFilterZoneMetric(namespace, "stream_filter_zone", "received", "Bytes received from clients", nil, constLabels),
+			"sent":        newFilterZoneMetric(namespace, "stream_filter_zone", "sent", "Bytes sent to clients", nil, constLabels),
+		},
+		streamUpstreamServerMetrics: map[string]*prometheus.Desc{
+			"state":           newStreamUpstreamServerMetric(namespace, "state", "Current state", nil, constLabels),
+			"connections":     newStreamUpstreamServerMetric(namespace, "connections", "Total number of client connections forwarded to this server", nil, constLabels),
+			"sent":            newStreamUpstreamServerMetric(namespace, "sent", "Bytes sent to this server", nil, constLabels),
+			"received":        newStreamUpstreamServerMetric(namespace, "received", "Bytes received from this server", nil, constLabels),
+			"connect_time":    newStreamUpstreamServerMetric(namespace, "connect_time", "Average time to connect to the upstream server", nil, constLabels),
+			"first_byte_time": newStreamUpstreamServerMetric(namespace, "first_byte_time", "Average time to receive the first byte of data", nil, constLabels),
+			"response_time":   newStreamUpstreamServerMetric(namespace, "response_time", "Average time to receive the last byte of data", nil, constLabels),
+		},
+		upMetric: newUpMetric(namespace, constLabels),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of the metrics
+// to the provided channel.
+func (c *VTSCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.upMetric.Desc()
+
+	for _, metrics := range []map[string]*prometheus.Desc{
+		c.totalMetrics, c.serverZoneMetrics, c.filterZoneMetrics, c.upstreamServerMetrics, c.cacheZoneMetrics,
+		c.streamServerZoneMetrics, c.streamFilterZoneMetrics, c.streamUpstreamServerMetrics,
+	} {
+		for _, m := range metrics {
+			ch <- m
+		}
+	}
+}
+
+// Collect fetches the JSON status and sends the metrics to the provided
+// channel.
+func (c *VTSCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+// CollectContext is like Collect but cancels the request to NGINX when ctx is done.
+func (c *VTSCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	_ = c.collect(ctx, ch)
+}
+
+func (c *VTSCollector) collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	c.mutex.Lock() // To protect metrics from concurrent collects
+	defer c.mutex.Unlock()
+
+	stats, err := c.vtsClient.GetStats(ctx)
+	if err != nil {
+		c.upMetric.Set(nginxDown)
+		ch <- c.upMetric
+		level.Warn(c.logger).Log("msg", "Error getting stats", "error", err.Error())
+		return err
+	}
+
+	c.upMetric.Set(nginxUp)
+	ch <- c.upMetric
+
+	ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_active"],
+		prometheus.GaugeValue, float64(stats.Connections.Active))
+	ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_accepted"],
+		prometheus.CounterValue, float64(stats.Connections.Accepted))
+	ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_handled"],
+		prometheus.CounterValue, float64(stats.Connections.Handled))
+	ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_reading"],
+		prometheus.GaugeValue, float64(stats.Connections.Reading))
+	ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_writing"],
+		prometheus.GaugeValue, float64(stats.Connections.Writing))
+	ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_waiting"],
+		prometheus.GaugeValue, float64(stats.Connections.Waiting))
+	ch <- prometheus.MustNewConstMetric(c.totalMetrics["http_requests_total"],
+		prometheus.CounterValue, float64(stats.Connections.Requests))
+
+	for name, zone := range stats.ServerZones {
+		c.collectServerZone(ch, c.serverZoneMetrics, zone, name)
+	}
+	for group, zones := range stats.FilterZones {
+		for name, zone := range zones {
+			c.collectServerZone(ch, c.filterZoneMetrics, zone, group, name)
+		}
+	}
+
+	for name, servers := range stats.UpstreamZones {
+		for _, server := range servers {
+			labelValues := []string{name, server.Server}
+			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["state"],
+				prometheus.GaugeValue, vtsUpstreamServerState(server.Down), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["requests"],
+				prometheus.CounterValue, float64(server.RequestCounter), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["sent"],
+				prometheus.CounterValue, float64(server.OutBytes), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["received"],
+				prometheus.CounterValue, float64(server.InBytes), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["response_time"],
+				prometheus.GaugeValue, float64(server.ResponseMsec), labelValues...)
+			collectVTSResponses(ch, c.upstreamServerMetrics["responses"], server.Responses, labelValues...)
+		}
+	}
+
+	for name, cache := range stats.CacheZones {
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["size"],
+			prometheus.GaugeValue, float64(cache.UsedSize), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["max_size"],
+			prometheus.GaugeValue, float64(cache.MaxSize), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["received"],
+			prometheus.CounterValue, float64(cache.InBytes), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["sent"],
+			prometheus.CounterValue, float64(cache.OutBytes), name)
+		for status, count := range map[string]int64{
+			"hit":         cache.Responses.Hit,
+			"stale":       cache.Responses.Stale,
+			"updating":    cache.Responses.Updating,
+			"revalidated": cache.Responses.Revalidated,
+			"miss":        cache.Responses.Miss,
+			"expired":     cache.Responses.Expired,
+			"bypass":      cache.Responses.Bypass,
+			"scarce":      cache.Responses.Scarce,
+		} {
+			ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics[status+"_responses"],
+				prometheus.CounterValue, float64(count), name)
+		}
+	}
+
+	for name, zone := range stats.StreamServerZones {
+		c.collectStreamServerZone(ch, c.streamServerZoneMetrics, zone, name)
+	}
+	for group, zones := range stats.StreamFilterZones {
+		for name, zone := range zones {
+			c.collectStreamServerZone(ch, c.streamFilterZoneMetrics, zone, group, name)
+		}
+	}
+
+	for name, servers := range stats.StreamUpstreamZones {
+		for _, server := range servers {
+			labelValues := []string{name, server.Server}
+			ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["state"],
+				prometheus.GaugeValue, vtsUpstreamServerState(server.Down), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["connections"],
+				prometheus.CounterValue, float64(server.ConnectCounter), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["sent"],
+				prometheus.CounterValue, float64(server.OutBytes), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["received"],
+				prometheus.CounterValue, float64(server.InBytes), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["connect_time"],
+				prometheus.GaugeValue, float64(server.UConnectMsec), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["first_byte_time"],
+				prometheus.GaugeValue, float64(server.UFirstByteMsec), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["response_time"],
+				prometheus.GaugeValue, float64(server.USessionMsec), labelValues...)
+		}
+	}
+
+	return nil
+}
+
+func (c *VTSCollector) collectServerZone(ch chan<- prometheus.Metric, metrics map[string]*prometheus.Desc, zone client.VTSServerZone, labelValues ...string) {
+	ch <- prometheus.MustNewConstMetric(metrics["requests"],
+		prometheus.CounterValue, float64(zone.RequestCounter), labelValues...)
+	ch <- prometheus.MustNewConstMetric(metrics["received"],
+		prometheus.CounterValue, float64(zone.InBytes), labelValues...)
+	ch <- prometheus.MustNewConstMetric(metrics["sent"],
+		prometheus.CounterValue, float64(zone.OutBytes), labelValues...)
+	ch <- prometheus.MustNewConstMetric(metrics["request_time"],
+		prometheus.GaugeValue, float64(zone.RequestMsec), labelValues...)
+	collectVTSResponses(ch, metrics["responses"], zone.Responses, labelValues...)
+}
+
+func (c *VTSCollector) collectStreamServerZone(ch chan<- prometheus.Metric, metrics map[string]*prometheus.Desc, zone client.VTSStreamServerZone, labelValues ...string) {
+	ch <- prometheus.MustNewConstMetric(metrics["connections"],
+		prometheus.CounterValue, float64(zone.ConnectCounter), labelValues...)
+	ch <- prometheus.MustNewConstMetric(metrics["received"],
+		prometheus.CounterValue, float64(zone.InBytes), labelValues...)
+	ch <- prometheus.MustNewConstMetric(metrics["sent"],
+		prometheus.CounterValue, float64(zone.OutBytes), labelValues...)
+	collectVTSResponses(ch, metrics["sessions"], zone.Responses, labelValues...)
+}
+
+// collectVTSResponses sends the responses by status code class.
+func collectVTSResponses(ch chan<- prometheus.Metric, desc *prometheus.Desc, responses client.VTSResponses, labelValues ...string) {
+	for class, count := range map[string]int64{
+		"1xx": responses.OneXX,
+		"2xx": responses.TwoXX,
+		"3xx": responses.ThreeXX,
+		"4xx": responses.FourXX,
+		"5xx": responses.FiveXX,
+	} {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(count), append(labelValues, class)...)
+	}
+}
+
+// vtsUpstreamServerState maps the down flag of an upstream server to the
+// states of NGINX Plus upstream servers.
+func vtsUpstreamServerState(down bool) float64 {
+	if down {
+		return upstreamServerStates["down"]
+	}
+	return upstreamServerStates["up"]
+}
+
+func newFilterZoneMetric(namespace string, subsystem string, metricName string, docString string, variableLabelNames []string, constLabels prometheus.Labels) *prometheus.Desc {
+	labels := []string{"filter_zone", "filter_name"}
+	labels = append(labels, variableLabelNames...)
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, metricName), docString, labels, constLabels)
+}
@@ -0,0 +1,69 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/nginxinc/nginx-prometheus-exporter/client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestVTSCollector(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.FileServer(http.Dir("../client/testdata/vts")))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		labels map[string]string
+		name   string
+		path   string
+		want   float64
+	}{
+		{path: "/status.json", name: "nginx_up", want: 1},
+		{path: "/status.json", name: "nginx_http_requests_total", want: 340},
+		{path: "/status.json", name: "nginx_connections_waiting", want: 2},
+		{path: "/status.json", name: "nginx_server_zone_requests", labels: map[string]string{"server_zone": "www.example.com"}, want: 300},
+		{path: "/status.json", name: "nginx_server_zone_responses", labels: map[string]string{"server_zone": "www.example.com", "code": "5xx"}, want: 1},
+		{path: "/status.json", name: "nginx_server_zone_request_time", labels: map[string]string{"server_zone": "www.example.com"}, want: 10},
+		{path: "/status.json", name: "nginx_filter_zone_responses", labels: map[string]string{"filter_zone": "country::www.example.com", "filter_name": "DE", "code": "4xx"}, want: 2},
+		{path: "/status.json", name: "nginx_upstream_server_state", labels: map[string]string{"upstream": "backend", "server": "10.0.0.1:8080"}, want: 1},
+		{path: "/status.json", name: "nginx_upstream_server_state", labels: map[string]string{"upstream": "backend", "server": "10.0.0.2:8080"}, want: 3},
+		{path: "/status.json", name: "nginx_upstream_server_received", labels: map[string]string{"upstream": "backend", "server": "10.0.0.1:8080"}, want: 1500000},
+		{path: "/status.json", name: "nginx_upstream_server_responses", labels: map[string]string{"upstream": "backend", "server": "10.0.0.1:8080", "code": "2xx"}, want: 195},
+		{path: "/status.json", name: "nginx_upstream_server_response_time", labels: map[string]string{"upstream": "backend", "server": "10.0.0.1:8080"}, want: 8},
+		{path: "/status.json", name: "nginx_cache_size", labels: map[string]string{"zone": "static"}, want: 5242880},
+		{path: "/status.json", name: "nginx_cache_hit_responses", labels: map[string]string{"zone": "static"}, want: 45},
+		{path: "/status.json", name: "nginx_cache_scarce_responses", labels: map[string]string{"zone": "static"}, want: 3},
+		{path: "/stream.json", name: "nginx_stream_server_zone_connections", labels: map[string]string{"server_zone": "TCP:5432:127.0.0.1"}, want: 25},
+		{path: "/stream.json", name: "nginx_stream_server_zone_sessions", labels: map[string]string{"server_zone": "TCP:5432:127.0.0.1", "code": "5xx"}, want: 1},
+		{path: "/stream.json", name: "nginx_stream_filter_zone_sent", labels: map[string]string{"filter_zone": "client::TCP:5432:127.0.0.1", "filter_name": "10.1.0.4"}, want: 40960},
+		{path: "/stream.json", name: "nginx_stream_upstream_server_connect_time", labels: map[string]string{"upstream": "postgres", "server": "10.0.1.1:5432"}, want: 2},
+		{path: "/missing.json", name: "nginx_up", want: 0},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := NewVTSCollector(client.NewVTSClient(srv.Client(), srv.URL+tt.path), "nginx", nil, log.NewNopLogger())
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(c)
+
+			families, err := registry.Gather()
+			if err != nil {
+				t.Fatalf("Gather() returned error: %v", err)
+			}
+
+			got, ok := metricValue(families, tt.name, tt.labels)
+			if !ok {
+				t.Fatalf("%s%v is missing", tt.name, tt.labels)
+			}
+			if got != tt.want {
+				t.Errorf("%s%v = %v, want %v", tt.name, tt.labels, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,368 @@
+// Package config loads the configuration file of the exporter.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/nginxinc/nginx-prometheus-exporter/accesslog"
+	"github.com/nginxinc/nginx-prometheus-exporter/errorlog"
+	"github.com/nginxinc/nginx-prometheus-exporter/syslog"
+	commoncfg "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ModeOSS scrapes the stub_status page of NGINX.
+	ModeOSS = "oss"
+	// ModePlus scrapes the NGINX Plus API.
+	ModePlus = "plus"
+	// ModeAuto detects whether the target serves the stub_status page or the
+	// NGINX Plus API.
+	ModeAuto = "auto"
+	// ModeAngie scrapes the JSON status API of Angie.
+	ModeAngie = "angie"
+	// ModeVTS scrapes the JSON status of the nginx-module-vts and
+	// nginx-module-sts modules.
+	ModeVTS = "vts"
+	// ModeReqStat scrapes the req_status_show page of the Tengine
+	// ngx_http_reqstat_module.
+	ModeReqStat = "reqstat"
+)
+
+// Modes lists the valid modes.
+var Modes = []string{ModeOSS, ModePlus, ModeAuto, ModeAngie, ModeVTS, ModeReqStat}
+
+var namespaceRE = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Config is the content of the exporter configuration file.
+type Config struct {
+	Modules    map[string]Module `yaml:"modules"`
+	Targets    []Target          `yaml:"targets"`
+	AccessLogs []AccessLog       `yaml:"access_logs"`
+	ErrorLogs  []ErrorLog        `yaml:"error_logs"`
+}
+
+// Module describes how the exporter talks to an NGINX instance. Modules are
+// referenced by the /probe endpoint and embedded in every target.
+type Module struct {
+	Headers      map[string]string   `yaml:"headers"`
+	Mode         string              `yaml:"mode"`
+	Namespace    string              `yaml:"namespace"`
+	TLSConfig    commoncfg.TLSConfig `yaml:"tls_config"`
+	Timeout      time.Duration       `yaml:"timeout"`
+	PollInterval time.Duration       `yaml:"poll_interval"`
+	PollMaxAge   time.Duration       `yaml:"poll_max_age"`
+}
+
+// Target is an NGINX instance scraped on every request to the metrics path.
+type Target struct {
+	ConstLabels map[string]string `yaml:"const_labels"`
+	Name        string            `yaml:"name"`
+	URI         string            `yaml:"uri"`
+	// ConfigFile is the NGINX configuration file of the target, whose
+	// maximum number of connections the active connections of a stub_status
+	// target are reported against.
+	ConfigFile string `yaml:"config_file"`
+	Module     `yaml:",inline"`
+}
+
+// AccessLog is a set of NGINX access log files, and syslog addresses receiving
+// access log lines, whose requests are counted.
+type AccessLog struct {
+	ConstLabels map[string]string `yaml:"const_labels"`
+	Name        string            `yaml:"name"`
+	Format      string            `yaml:"format"`
+	Namespace   string            `yaml:"namespace"`
+	Paths       []string          `yaml:"paths"`
+	Syslog      []string          `yaml:"syslog"`
+	Hosts       []string          `yaml:"hosts"`
+	Routes      []Route           `yaml:"routes"`
+	Histograms  Histograms        `yaml:"histograms"`
+}
+
+// Histograms configures the histograms of the request and upstream times.
+type Histograms struct {
+	Buckets []float64 `yaml:"buckets"`
+	Native  bool      `yaml:"native"`
+}
+
+// Route names the requests whose path matches the regular expression Match.
+type Route struct {
+	Name  string `yaml:"name"`
+	Match string `yaml:"match"`
+}
+
+// ErrorLog is a set of NGINX error log files, and syslog addresses receiving
+// error log lines, whose entries are counted.
+type ErrorLog struct {
+	ConstLabels map[string]string `yaml:"const_labels"`
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace"`
+	Paths       []string          `yaml:"paths"`
+	Syslog      []string          `yaml:"syslog"`
+	Categories  []Category        `yaml:"categories"`
+}
+
+// Category names the error log entries whose message matches the regular
+// expression Match.
+type Category struct {
+	Name  string `yaml:"name"`
+	Match string `yaml:"match"`
+}
+
+func (m *Module) validate() error {
+	switch m.Mode {
+	case "":
+		m.Mode = ModeOSS
+	case ModeOSS, ModePlus, ModeAuto, ModeAngie, ModeVTS, ModeReqStat:
+	default:
+		return fmt.Errorf("unknown mode %q, must be one of %s", m.Mode, strings.Join(Modes, ", "))
+	}
+	if m.Timeout < 0 {
+		return fmt.Errorf("negative timeout %v is not valid", m.Timeout)
+	}
+	if m.PollInterval < 0 {
+		return fmt.Errorf("negative poll interval %v is not valid", m.PollInterval)
+	}
+	if m.PollMaxAge < 0 {
+		return fmt.Errorf("negative poll max age %v is not valid", m.PollMaxAge)
+	}
+	if m.Namespace != "" && !namespaceRE.MatchString(m.Namespace) {
+		return fmt.Errorf("invalid namespace %q", m.Namespace)
+	}
+	return nil
+}
+
+func (t *Target) validate() error {
+	if err := t.Module.validate(); err != nil {
+		return err
+	}
+	if t.URI == "" {
+		return errors.New("uri must not be empty")
+	}
+	if !strings.HasPrefix(t.URI, "unix:") {
+		u, err := url.Parse(t.URI)
+		if err != nil {
+			return fmt.Errorf("invalid uri: %w", err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid uri %q, must be an http or https URL or a unix domain socket address", t.URI)
+		}
+	}
+	for name := range t.ConstLabels {
+		if !model.LabelName(name).IsValid() {
+			return fmt.Errorf("invalid const label name %q", name)
+		}
+	}
+	return nil
+}
+
+func (l *AccessLog) validate() error {
+	if len(l.Paths) == 0 && len(l.Syslog) == 0 {
+		return errors.New("paths and syslog must not both be empty")
+	}
+	for _, addr := range l.Syslog {
+		if _, _, err := syslog.ParseAddress(addr); err != nil {
+			return err
+		}
+	}
+	if _, err := accesslog.NewParser(l.Format); err != nil {
+		return err
+	}
+	if l.Namespace != "" && !namespaceRE.MatchString(l.Namespace) {
+		return fmt.Errorf("invalid namespace %q", l.Namespace)
+	}
+	for name := range l.ConstLabels {
+		if !model.LabelName(name).IsValid() {
+			return fmt.Errorf("invalid const label name %q", name)
+		}
+	}
+	for _, host := range l.Hosts {
+		if host == "" {
+			return errors.New("host must not be empty")
+		}
+	}
+	for _, route := range l.Routes {
+		if route.Name == "" {
+			return errors.New("route name must not be empty")
+		}
+		if _, err := regexp.Compile(route.Match); err != nil {
+			return fmt.Errorf("route %q: invalid match: %w", route.Name, err)
+		}
+	}
+	for i := 1; i < len(l.Histograms.Buckets); i++ {
+		if l.Histograms.Buckets[i] <= l.Histograms.Buckets[i-1] {
+			return errors.New("histogram buckets must be in increasing order")
+		}
+	}
+	return nil
+}
+
+func (l *ErrorLog) validate() error {
+	if len(l.Paths) == 0 && len(l.Syslog) == 0 {
+		return errors.New("paths and syslog must not both be empty")
+	}
+	for _, addr := range l.Syslog {
+		if _, _, err := syslog.ParseAddress(addr); err != nil {
+			return err
+		}
+	}
+	if l.Namespace != "" && !namespaceRE.MatchString(l.Namespace) {
+		return fmt.Errorf("invalid namespace %q", l.Namespace)
+	}
+	for name := range l.ConstLabels {
+		if !model.LabelName(name).IsValid() {
+			return fmt.Errorf("invalid const label name %q", name)
+		}
+	}
+	for _, category := range l.Categories {
+		if category.Name == "" {
+			return errors.New("category name must not be empty")
+		}
+		for _, c := range errorlog.Categories {
+			if c.Name == category.Name {
+				return fmt.Errorf("category %q is built in", category.Name)
+			}
+		}
+		if _, err := regexp.Compile(category.Match); err != nil {
+			return fmt.Errorf("category %q: invalid match: %w", category.Name, err)
+		}
+	}
+	return nil
+}
+
+// Load parses the YAML input s into a Config and validates it.
+func Load(s string) (*Config, error) {
+	cfg := &Config{}
+	if err := yaml.UnmarshalStrict([]byte(s), cfg); err != nil {
+		return nil, err
+	}
+
+	for name, m := range cfg.Modules {
+		if name == "" {
+			return nil, errors.New("module name must not be empty")
+		}
+		if err := m.validate(); err != nil {
+			return nil, fmt.Errorf("module %q: %w", name, err)
+		}
+		cfg.Modules[name] = m
+	}
+
+	names := make(map[string]bool, len(cfg.Targets))
+	for i := range cfg.Targets {
+		t := &cfg.Targets[i]
+		if t.Name == "" {
+			return nil, fmt.Errorf("target #%d: name must not be empty", i+1)
+		}
+		if names[t.Name] {
+			return nil, fmt.Errorf("target %q: duplicate target name", t.Name)
+		}
+		names[t.Name] = true
+		if err := t.validate(); err != nil {
+			return nil, fmt.Errorf("target %q: %w", t.Name, err)
+		}
+	}
+
+	// Only one log can listen on a syslog address.
+	syslogOwners := make(map[[2]string]string)
+
+	names = make(map[string]bool, len(cfg.AccessLogs))
+	for i := range cfg.AccessLogs {
+		l := &cfg.AccessLogs[i]
+		if l.Name == "" {
+			return nil, fmt.Errorf("access log #%d: name must not be empty", i+1)
+		}
+		if names[l.Name] {
+			return nil, fmt.Errorf("access log %q: duplicate access log name", l.Name)
+		}
+		names[l.Name] = true
+		if err := l.validate(); err != nil {
+			return nil, fmt.Errorf("access log %q: %w", l.Name, err)
+		}
+		if err := claimSyslog(syslogOwners, fmt.Sprintf("access log %q", l.Name), l.Syslog); err != nil {
+			return nil, err
+		}
+	}
+
+	names = make(map[string]bool, len(cfg.ErrorLogs))
+	for i := range cfg.ErrorLogs {
+		l := &cfg.ErrorLogs[i]
+		if l.Name == "" {
+			return nil, fmt.Errorf("error log #%d: name must not be empty", i+1)
+		}
+		if names[l.Name] {
+			return nil, fmt.Errorf("error log %q: duplicate error log name", l.Name)
+		}
+		names[l.Name] = true
+		if err := l.validate(); err != nil {
+			return nil, fmt.Errorf("error log %q: %w", l.Name, err)
+		}
+		if err := claimSyslog(syslogOwners, fmt.Sprintf("error log %q", l.Name), l.Syslog); err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// claimSyslog records owner as the log listening on the syslog addresses, which
+// must be valid, and fails when another log, or owner itself, already listens
+// on one of them.
+func claimSyslog(owners map[[2]string]string, owner string, addresses []string) error {
+	for _, addr := range addresses {
+		network, address, _ := syslog.ParseAddress(addr)
+		key := [2]string{network, address}
+		if previous, ok := owners[key]; ok {
+			return fmt.Errorf("%s: syslog address %q is already used by %s", owner, addr, previous)
+		}
+		owners[key] = owner
+	}
+	return nil
+}
+
+// LoadFile parses the given YAML file into a Config. Relative TLS file paths
+// and log paths are resolved against the directory of the file.
+func LoadFile(filename string) (*Config, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	cfg, err := Load(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", filename, err)
+	}
+	dir := filepath.Dir(filename)
+	for name, m := range cfg.Modules {
+		m.TLSConfig.SetDirectory(dir)
+		cfg.Modules[name] = m
+	}
+	for i := range cfg.Targets {
+		cfg.Targets[i].TLSConfig.SetDirectory(dir)
+		if path := cfg.Targets[i].ConfigFile; path != "" && !filepath.IsAbs(path) {
+			cfg.Targets[i].ConfigFile = filepath.Join(dir, path)
+		}
+	}
+	for i := range cfg.AccessLogs {
+		for j, path := range cfg.AccessLogs[i].Paths {
+			if !filepath.IsAbs(path) {
+				cfg.AccessLogs[i].Paths[j] = filepath.Join(dir, path)
+			}
+		}
+	}
+	for i := range cfg.ErrorLogs {
+		for j, path := range cfg.ErrorLogs[i].Paths {
+			if !filepath.IsAbs(path) {
+				cfg.ErrorLogs[i].Paths[j] = filepath.Join(dir, path)
+			}
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,425 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{
+			name: "valid modules",
+			input: `
+modules:
+  oss:
+    mode: oss
+  angie:
+    mode: angie
+  vts:
+    mode: vts
+  reqstat:
+    mode: reqstat
+  plus:
+    mode: plus
+    timeout: 2s
+    headers:
+      Authorization: Bearer token
+    tls_config:
+      insecure_skip_verify: true
+`,
+		},
+		{
+			name: "unknown mode",
+			input: `
+modules:
+  broken:
+    mode: apache
+`,
+			wantErr: true,
+		},
+		{
+			name: "negative timeout",
+			input: `
+modules:
+  broken:
+    timeout: -1s
+`,
+			wantErr: true,
+		},
+		{
+			name: "negative poll interval",
+			input: `
+modules:
+  broken:
+    poll_interval: -15s
+`,
+			wantErr: true,
+		},
+		{
+			name: "unknown field",
+			input: `
+modules:
+  broken:
+    scrape_uri: http://127.0.0.1/stub_status
+`,
+			wantErr: true,
+		},
+		{
+			name: "valid targets",
+			input: `
+targets:
+  - name: edge
+    uri: http://127.0.0.1:8080/stub_status
+    const_labels:
+      tier: edge
+  - name: api
+    uri: unix:/var/run/nginx.sock:/api
+    mode: plus
+    namespace: nginx_api
+    timeout: 1s
+    poll_interval: 15s
+    poll_max_age: 1m
+`,
+		},
+		{
+			name: "target without name",
+			input: `
+targets:
+  - uri: http://127.0.0.1:8080/stub_status
+`,
+			wantErr: true,
+		},
+		{
+			name: "duplicate target name",
+			input: `
+targets:
+  - name: edge
+    uri: http://127.0.0.1:8080/stub_status
+  - name: edge
+    uri: http://127.0.0.1:8081/stub_status
+`,
+			wantErr: true,
+		},
+		{
+			name: "target without uri",
+			input: `
+targets:
+  - name: edge
+`,
+			wantErr: true,
+		},
+		{
+			name: "target with unsupported uri scheme",
+			input: `
+targets:
+  - name: edge
+    uri: ftp://127.0.0.1/stub_status
+`,
+			wantErr: true,
+		},
+		{
+			name: "target with invalid namespace",
+			input: `
+targets:
+  - name: edge
+    uri: http://127.0.0.1:8080/stub_status
+    namespace: nginx-edge
+`,
+			wantErr: true,
+		},
+		{
+			name: "target with invalid const label",
+			input: `
+targets:
+  - name: edge
+    uri: http://127.0.0.1:8080/stub_status
+    const_labels:
+      1tier: edge
+`,
+			wantErr: true,
+		},
+		{
+			name: "target with invalid mode",
+			input: `
+targets:
+  - name: edge
+    uri: http://127.0.0.1:8080/stub_status
+    mode: apache
+`,
+			wantErr: true,
+		},
+		{
+			name: "valid access logs",
+			input: `
+access_logs:
+  - name: web
+    paths:
+      - /var/log/nginx/access.log
+  - name: api
+    paths:
+      - /var/log/nginx/api.log
+    format: '$host "$request" $status $bytes_sent $request_length'
+    namespace: nginx_api
+    const_labels:
+      tier: api
+    hosts: [api.example.com, "*.api.example.com"]
+    routes:
+      - name: users
+        match: ^/api/users(/|$)
+`,
+		},
+		{
+			name: "valid error logs",
+			input: `
+error_logs:
+  - name: main
+    paths: [/var/log/nginx/error.log]
+    categories:
+      - name: ssl_handshake
+        match: SSL_do_handshake\(\) failed
+  - name: containers
+    syslog: [unix:/run/nginx-exporter/error.sock]
+`,
+		},
+		{
+			name: "error log without paths or syslog",
+			input: `
+error_logs:
+  - name: main
+`,
+			wantErr: true,
+		},
+		{
+			name: "error log with built-in category name",
+			input: `
+error_logs:
+  - name: main
+    paths: [/var/log/nginx/error.log]
+    categories:
+      - name: upstream_timed_out
+        match: timed out
+`,
+			wantErr: true,
+		},
+		{
+			name: "error log with invalid category match",
+			input: `
+error_logs:
+  - name: main
+    paths: [/var/log/nginx/error.log]
+    categories:
+      - name: broken
+        match: "("
+`,
+			wantErr: true,
+		},
+		{
+			name: "duplicate error log name",
+			input: `
+error_logs:
+  - name: main
+    paths: [/var/log/nginx/error.log]
+  - name: main
+    paths: [/var/log/nginx/other.log]
+`,
+			wantErr: true,
+		},
+		{
+			name: "access log received over syslog",
+			input: `
+access_logs:
+  - name: web
+    syslog:
+      - udp://127.0.0.1:5514
+      - unix:/run/nginx-exporter/syslog.sock
+`,
+		},
+		{
+			name: "access log with histograms",
+			input: `
+access_logs:
+  - name: web
+    paths: [/var/log/nginx/access.log]
+    format: json
+    histograms:
+      buckets: [0.01, 0.1, 1, 10]
+      native: true
+`,
+		},
+		{
+			name: "access log with unordered histogram buckets",
+			input: `
+access_logs:
+  - name: web
+    paths: [/var/log/nginx/access.log]
+    histograms:
+      buckets: [0.1, 0.01]
+`,
+			wantErr: true,
+		},
+		{
+			name: "access log with invalid syslog address",
+			input: `
+access_logs:
+  - name: web
+    syslog: [tcp://127.0.0.1:5514]
+`,
+			wantErr: true,
+		},
+		{
+			name: "access log without paths or syslog",
+			input: `
+access_logs:
+  - name: web
+`,
+			wantErr: true,
+		},
+		{
+			name: "duplicate access log name",
+			input: `
+access_logs:
+  - name: web
+    paths: [/var/log/nginx/access.log]
+  - name: web
+    paths: [/var/log/nginx/other.log]
+`,
+			wantErr: true,
+		},
+		{
+			name: "access log format without variables",
+			input: `
+access_logs:
+  - name: web
+    paths: [/var/log/nginx/access.log]
+    format: main
+`,
+			wantErr: true,
+		},
+		{
+			name: "access log route with invalid match",
+			input: `
+access_logs:
+  - name: web
+    paths: [/var/log/nginx/access.log]
+    routes:
+      - name: api
+        match: "^/api/("
+`,
+			wantErr: true,
+		},
+		{
+			name: "empty access log host",
+			input: `
+access_logs:
+  - name: web
+    paths: [/var/log/nginx/access.log]
+    hosts: [example.com, ""]
+`,
+			wantErr: true,
+		},
+		{
+			name: "access logs sharing a syslog address",
+			input: `
+access_logs:
+  - name: web
+    syslog: [udp://127.0.0.1:5514]
+  - name: api
+    syslog: [udp://127.0.0.1:5514]
+`,
+			wantErr: true,
+		},
+		{
+			name: "access log repeating a syslog address",
+			input: `
+access_logs:
+  - name: web
+    syslog: [unix:/run/nginx-exporter/syslog.sock, unix:/run/nginx-exporter/syslog.sock]
+`,
+			wantErr: true,
+		},
+		{
+			name: "access log and error log sharing a syslog address",
+			input: `
+access_logs:
+  - name: web
+    syslog: [udp://127.0.0.1:5514]
+error_logs:
+  - name: web
+    syslog: [udp://127.0.0.1:5514]
+`,
+			wantErr: true,
+		},
+		{
+			name: "logs on distinct syslog addresses",
+			input: `
+access_logs:
+  - name: web
+    syslog: [udp://127.0.0.1:5514, unix:/run/nginx-exporter/access.sock]
+error_logs:
+  - name: web
+    syslog: [udp://127.0.0.1:5515, unix:/run/nginx-exporter/error.sock]
+`,
+		},
+		{
+			name: "client cert without key",
+			input: `
+modules:
+  broken:
+    tls_config:
+      cert_file: client.crt
+`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := Load(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadModuleDefaults(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := Load("modules:\n  default: {}\n  plus:\n    mode: plus\n    timeout: 2s\n")
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+
+	if got := cfg.Modules["default"].Mode; got != ModeOSS {
+		t.Errorf("default module mode = %q, want %q", got, ModeOSS)
+	}
+	if got := cfg.Modules["plus"].Mode; got != ModePlus {
+		t.Errorf("plus module mode = %q, want %q", got, ModePlus)
+	}
+	if got := cfg.Modules["plus"].Timeout; got != 2*time.Second {
+		t.Errorf("plus module timeout = %v, want %v", got, 2*time.Second)
+	}
+}
+
+func TestLoadTargetDefaults(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := Load("targets:\n  - name: edge\n    uri: http://127.0.0.1:8080/stub_status\n")
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+
+	if len(cfg.Targets) != 1 {
+		t.Fatalf("Load() returned %d targets, want 1", len(cfg.Targets))
+	}
+	if got := cfg.Targets[0].Mode; got != ModeOSS {
+		t.Errorf("target mode = %q, want %q", got, ModeOSS)
+	}
+}
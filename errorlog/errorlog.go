@@ -0,0 +1,92 @@
+// Package errorlog parses NGINX error log lines and classifies well-known
+// messages.
+package errorlog
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ErrNoMatch means a line is not an NGINX error log entry.
+var ErrNoMatch = errors.New("line is not an error log entry")
+
+// Severities are the levels of error log entries, from the lowest.
+var Severities = []string{"debug", "info", "notice", "warn", "error", "crit", "alert", "emerg"}
+
+// lineRE matches "yyyy/mm/dd hh:mm:ss [level] pid#tid: message".
+var lineRE = regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} \[([a-z]+)\] \d+#\d+: (.*)$`)
+
+// upstreamRE matches the upstream of the context NGINX appends to messages,
+// such as `upstream: "http://10.0.0.1:8080/api"`.
+var upstreamRE = regexp.MustCompile(`, upstream: "([^"]*)"`)
+
+// Entry is an error log entry.
+type Entry struct {
+	Severity string
+	Message  string
+	// Upstream is the host of the upstream the entry is about, which is the
+	// name of the upstream group or the address of the server. It is empty
+	// when the entry is not about an upstream.
+	Upstream string
+}
+
+// Parse parses an error log line.
+func Parse(line string) (Entry, error) {
+	m := lineRE.FindStringSubmatch(line)
+	if m == nil || !slices.Contains(Severities, m[1]) {
+		return Entry{}, ErrNoMatch
+	}
+	e := Entry{
+		Severity: m[1],
+		Message:  m[2],
+	}
+	if m := upstreamRE.FindStringSubmatch(e.Message); m != nil {
+		e.Upstream = upstreamHost(m[1])
+	}
+	return e, nil
+}
+
+// upstreamHost returns the host of an upstream URL, or the upstream itself
+// when it is not a URL.
+func upstreamHost(upstream string) string {
+	if !strings.Contains(upstream, "://") {
+		return upstream
+	}
+	u, err := url.Parse(upstream)
+	if err != nil || u.Host == "" {
+		return upstream
+	}
+	return u.Host
+}
+
+// Category names the entries whose message matches Regexp.
+type Category struct {
+	Regexp *regexp.Regexp
+	Name   string
+}
+
+// Categories are the built-in categories of well-known messages.
+var Categories = []Category{
+	{Name: "upstream_timed_out", Regexp: regexp.MustCompile(`upstream timed out`)},
+	{Name: "no_live_upstreams", Regexp: regexp.MustCompile(`no live upstreams`)},
+	{Name: "upstream_connection_refused", Regexp: regexp.MustCompile(`connect\(\) (to \S+ )?failed \(111: Connection refused\)`)},
+	{Name: "upstream_prematurely_closed", Regexp: regexp.MustCompile(`upstream prematurely closed connection`)},
+	{Name: "worker_exited_on_signal", Regexp: regexp.MustCompile(`worker process \d+ exited on signal`)},
+	{Name: "too_many_open_files", Regexp: regexp.MustCompile(`Too many open files`)},
+	{Name: "worker_connections_not_enough", Regexp: regexp.MustCompile(`worker_connections are not enough`)},
+}
+
+// Match returns the names of the categories matching the message of the
+// entry.
+func Match(categories []Category, e Entry) []string {
+	var names []string
+	for _, c := range categories {
+		if c.Regexp.MatchString(e.Message) {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,95 @@
+package errorlog
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		line    string
+		want    Entry
+		wantErr bool
+	}{
+		{
+			name: "upstream timed out",
+			line: `2023/10/10 13:55:36 [error] 31#31: *5 upstream timed out (110: Connection timed out) while reading response header from upstream, client: 10.0.0.9, server: example.com, request: "GET /api HTTP/1.1", upstream: "http://10.0.0.2:8080/api", host: "example.com"`,
+			want: Entry{
+				Severity: "error",
+				Message:  `*5 upstream timed out (110: Connection timed out) while reading response header from upstream, client: 10.0.0.9, server: example.com, request: "GET /api HTTP/1.1", upstream: "http://10.0.0.2:8080/api", host: "example.com"`,
+				Upstream: "10.0.0.2:8080",
+			},
+		},
+		{
+			name: "no live upstreams",
+			line: `2023/10/10 13:55:36 [error] 31#31: *7 no live upstreams while connecting to upstream, client: 10.0.0.9, server: example.com, request: "GET / HTTP/1.1", upstream: "http://backend/", host: "example.com"`,
+			want: Entry{
+				Severity: "error",
+				Message:  `*7 no live upstreams while connecting to upstream, client: 10.0.0.9, server: example.com, request: "GET / HTTP/1.1", upstream: "http://backend/", host: "example.com"`,
+				Upstream: "backend",
+			},
+		},
+		{
+			name: "without upstream",
+			line: `2023/10/10 13:55:36 [alert] 1#1: worker process 42 exited on signal 11 (core dumped)`,
+			want: Entry{Severity: "alert", Message: "worker process 42 exited on signal 11 (core dumped)"},
+		},
+		{
+			name:    "unknown severity",
+			line:    `2023/10/10 13:55:36 [fatal] 1#1: message`,
+			wantErr: true,
+		},
+		{
+			name:    "not an entry",
+			line:    `nginx: [emerg] unknown directive "foo"`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Parse(tt.line)
+			if tt.wantErr {
+				if !errors.Is(err, ErrNoMatch) {
+					t.Errorf("Parse() error = %v, want %v", err, ErrNoMatch)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchCategories(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		message string
+		want    []string
+	}{
+		{message: "*5 upstream timed out (110: Connection timed out) while connecting to upstream", want: []string{"upstream_timed_out"}},
+		{message: "*7 no live upstreams while connecting to upstream", want: []string{"no_live_upstreams"}},
+		{message: "*3 connect() failed (111: Connection refused) while connecting to upstream", want: []string{"upstream_connection_refused"}},
+		{message: "*9 upstream prematurely closed connection while reading response header from upstream", want: []string{"upstream_prematurely_closed"}},
+		{message: "worker process 42 exited on signal 9", want: []string{"worker_exited_on_signal"}},
+		{message: "accept4() failed (24: Too many open files)", want: []string{"too_many_open_files"}},
+		{message: "1024 worker_connections are not enough", want: []string{"worker_connections_not_enough"}},
+		{message: `*1 open() "/usr/share/nginx/html/favicon.ico" failed (2: No such file or directory)`},
+	}
+	for _, tt := range tests {
+		if got := Match(Categories, Entry{Message: tt.message}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Match(%q) = %v, want %v", tt.message, got, tt.want)
+		}
+	}
+}
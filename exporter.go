@@ -2,28 +2,34 @@ package main
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"errors"
 	"fmt"
-	"maps"
+	"math"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
-	plusclient "github.com/nginxinc/nginx-plus-go-client/client"
+	"github.com/nginxinc/nginx-prometheus-exporter/accesslog"
 	"github.com/nginxinc/nginx-prometheus-exporter/client"
 	"github.com/nginxinc/nginx-prometheus-exporter/collector"
+	"github.com/nginxinc/nginx-prometheus-exporter/config"
+	"github.com/nginxinc/nginx-prometheus-exporter/errorlog"
+	"github.com/nginxinc/nginx-prometheus-exporter/syslog"
+	"github.com/nginxinc/nginx-prometheus-exporter/tail"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	commoncfg "github.com/prometheus/common/config"
 	"github.com/prometheus/common/promlog"
 	"github.com/prometheus/common/promlog/flag"
 	"github.com/prometheus/common/version"
@@ -81,22 +87,42 @@ func parseUnixSocketAddress(address string) (string, string, error) {
 var (
 	constLabels = map[string]string{}
 
+	// restarts keeps the restarts of the stub_status targets detected by the
+	// collectors of /metrics and of /probe across reloads and probes.
+	restarts = collector.NewRestartTracker()
+
 	// Command-line flags
 	webConfig     = kingpinflag.AddFlags(kingpin.CommandLine, ":9113")
 	metricsPath   = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").Envar("TELEMETRY_PATH").String()
+	configFile    = kingpin.Flag("config.file", "Path to the exporter configuration file with the scrape targets and the modules for the /probe endpoint.").Default("").Envar("EXPORTER_CONFIG_FILE").String()
 	nginxPlus     = kingpin.Flag("nginx.plus", "Start the exporter for NGINX Plus. By default, the exporter is started for NGINX.").Default("false").Envar("NGINX_PLUS").Bool()
+	nginxMode     = kingpin.Flag("nginx.mode", "Mode of the exporter: oss for the stub_status page, plus for the NGINX Plus API, auto to detect either of them for every scrape URI, angie for the Angie status API, vts for the JSON status of nginx-module-vts and nginx-module-sts or reqstat for the req_status_show page of Tengine. Overrides --nginx.plus.").Envar("NGINX_MODE").Enum(config.Modes...)
 	scrapeURIs    = kingpin.Flag("nginx.scrape-uri", "A URI or unix domain socket path for scraping NGINX or NGINX Plus metrics. For NGINX, the stub_status page must be available through the URI. For NGINX Plus -- the API. Repeatable for multiple URIs.").Default("http://127.0.0.1:8080/stub_status").Envar("SCRAPE_URI").HintOptions("http://127.0.0.1:8080/stub_status", "http://127.0.0.1:8080/api").Strings()
 	sslVerify     = kingpin.Flag("nginx.ssl-verify", "Perform SSL certificate verification.").Default("false").Envar("SSL_VERIFY").Bool()
 	sslCaCert     = kingpin.Flag("nginx.ssl-ca-cert", "Path to the PEM encoded CA certificate file used to validate the servers SSL certificate.").Default("").Envar("SSL_CA_CERT").String()
 	sslClientCert = kingpin.Flag("nginx.ssl-client-cert", "Path to the PEM encoded client certificate file to use when connecting to the server.").Default("").Envar("SSL_CLIENT_CERT").String()
 	sslClientKey  = kingpin.Flag("nginx.ssl-client-key", "Path to the PEM encoded client certificate key file to use when connecting to the server.").Default("").Envar("SSL_CLIENT_KEY").String()
+	pidFile       = kingpin.Flag("nginx.pid-file", "Path to the pid file of the NGINX master process, to report the metrics of the NGINX processes.").Default("").Envar("NGINX_PID_FILE").String()
+	processName   = kingpin.Flag("nginx.process-name", "Name of the NGINX processes, to report their metrics when no pid file is set.").Default("").Envar("NGINX_PROCESS_NAME").String()
+	nginxConfFile = kingpin.Flag("nginx.config-file", "Path to the NGINX configuration file, to report its settings and its TLS certificates.").Default("").Envar("NGINX_CONFIG_FILE").String()
+	certGlobs     = kingpin.Flag("nginx.certificate-glob", "Glob of PEM encoded TLS certificate files to report the validity of, in addition to the certificates of --nginx.config-file. Repeatable for multiple globs.").Envar("NGINX_CERTIFICATE_GLOB").Strings()
+	listenAddrs   = kingpin.Flag("nginx.listen-address", "Address of an NGINX listen socket to report the accept queue of, in the format of the listen directive, such as 80 or 127.0.0.1:8080. The addresses of --nginx.config-file are reported too. Repeatable for multiple addresses.").Envar("NGINX_LISTEN_ADDRESS").Strings()
+	buildInfo     = kingpin.Flag("nginx.build-info", "Report the version and the modules of NGINX from the output of --nginx.build-info-command.").Default("false").Envar("NGINX_BUILD_INFO").Bool()
+	buildInfoCmd  = kingpin.Flag("nginx.build-info-command", "Command printing the build information of NGINX. It runs again when its executable changes.").Default("nginx -V").Envar("NGINX_BUILD_INFO_COMMAND").String()
+	procRoot      = kingpin.Flag("nginx.proc-root", "Mount point of the proc filesystem to read the NGINX processes and listen sockets from.").Default("/proc").Envar("NGINX_PROC_ROOT").String()
 
 	// Custom command-line flags
-	timeout = createPositiveDurationFlag(kingpin.Flag("nginx.timeout", "A timeout for scraping metrics from NGINX or NGINX Plus.").Default("5s").Envar("TIMEOUT").HintOptions("5s", "10s", "30s", "1m", "5m"))
+	timeout       = createPositiveDurationFlag(kingpin.Flag("nginx.timeout", "A timeout for scraping metrics from NGINX or NGINX Plus.").Default("5s").Envar("TIMEOUT").HintOptions("5s", "10s", "30s", "1m", "5m"))
+	pollInterval  = createPositiveDurationFlag(kingpin.Flag("nginx.poll-interval", "Interval to poll every target in the background, serving the metrics of the last poll on scrape. By default, the targets are requested on every scrape.").Default("0s").Envar("POLL_INTERVAL"))
+	pollMaxAge    = createPositiveDurationFlag(kingpin.Flag("nginx.poll-max-age", "Age of the last successful poll after which a polled target is reported down and its metrics are dropped. Defaults to three poll intervals.").Default("0s").Envar("POLL_MAX_AGE"))
+	timeoutOffset = createPositiveDurationFlag(kingpin.Flag("prometheus.scrape-timeout-offset", "Offset subtracted from the scrape timeout sent by Prometheus to leave time for the exporter to respond.").Default("500ms").Envar("SCRAPE_TIMEOUT_OFFSET"))
 )
 
 const exporterName = "nginx_exporter"
 
+// tailInterval is the interval to check the tailed files for new lines.
+const tailInterval = time.Second
+
 func main() {
 	kingpin.Flag("prometheus.const-label", "Label that will be used in every metric. Format is label=value. It can be repeated multiple times.").Envar("CONST_LABELS").StringMapVar(&constLabels)
 
@@ -125,54 +151,70 @@ func main() {
 
 	prometheus.MustRegister(version.NewCollector(exporterName))
 
-	if len(*scrapeURIs) == 0 {
-		level.Error(logger).Log("msg", "No scrape addresses provided")
-		os.Exit(1)
-	}
-
-	// #nosec G402
-	sslConfig := &tls.Config{InsecureSkipVerify: !*sslVerify}
-	if *sslCaCert != "" {
-		caCert, err := os.ReadFile(*sslCaCert)
+	var lastReload, workers func() (float64, bool)
+	if *pidFile != "" || *processName != "" {
+		c, err := collector.NewProcessCollector(*procRoot, *pidFile, *processName, "nginx", constLabels, logger)
 		if err != nil {
-			level.Error(logger).Log("msg", "Loading CA cert failed", "err", err.Error())
+			level.Error(logger).Log("msg", "Creating the process collector failed", "error", err.Error())
 			os.Exit(1)
 		}
-		sslCaCertPool := x509.NewCertPool()
-		ok := sslCaCertPool.AppendCertsFromPEM(caCert)
-		if !ok {
-			level.Error(logger).Log("msg", "Parsing CA cert file failed.")
+		prometheus.MustRegister(c)
+		lastReload = c.LastReload
+		workers = c.Workers
+	}
+	if *nginxConfFile != "" {
+		prometheus.MustRegister(collector.NewNginxConfigCollector(*nginxConfFile, lastReload, workers, "nginx", constLabels, logger))
+	}
+	if *nginxConfFile != "" || len(*certGlobs) > 0 {
+		prometheus.MustRegister(collector.NewCertificateCollector(*nginxConfFile, *certGlobs, "nginx", constLabels, logger))
+	}
+	if *nginxConfFile != "" || len(*listenAddrs) > 0 {
+		c, err := collector.NewListenCollector(*procRoot, *nginxConfFile, *listenAddrs, "nginx", constLabels, logger)
+		switch {
+		case err == nil:
+			prometheus.MustRegister(c)
+		case len(*listenAddrs) > 0:
+			level.Error(logger).Log("msg", "Creating the listen socket collector failed", "error", err.Error())
 			os.Exit(1)
+		default:
+			// The configuration file alone does not require a proc
+			// filesystem, which is missing outside of Linux.
+			level.Warn(logger).Log("msg", "Listen socket metrics are disabled", "error", err.Error())
 		}
-		sslConfig.RootCAs = sslCaCertPool
 	}
 
-	if *sslClientCert != "" && *sslClientKey != "" {
-		clientCert, err := tls.LoadX509KeyPair(*sslClientCert, *sslClientKey)
+	if *buildInfo {
+		c, err := collector.NewBuildInfoCollector(strings.Fields(*buildInfoCmd), "nginx", constLabels, logger)
 		if err != nil {
-			level.Error(logger).Log("msg", "Loading client certificate failed", "error", err.Error())
+			level.Error(logger).Log("msg", "Creating the build information collector failed", "error", err.Error())
 			os.Exit(1)
 		}
-		sslConfig.Certificates = []tls.Certificate{clientCert}
+		prometheus.MustRegister(c)
 	}
 
-	transport := &http.Transport{
-		TLSClientConfig: sslConfig,
+	manager := newTargetManager(prometheus.DefaultRegisterer, loadConfig, logger)
+	if err := manager.Reload(); err != nil {
+		level.Error(logger).Log("msg", "Loading configuration failed", "error", err.Error())
+		os.Exit(1)
 	}
 
-	if len(*scrapeURIs) == 1 {
-		registerCollector(logger, transport, (*scrapeURIs)[0], constLabels)
-	} else {
-		for _, addr := range *scrapeURIs {
-			// add scrape URI to const labels
-			labels := maps.Clone(constLabels)
-			labels["addr"] = addr
-
-			registerCollector(logger, transport, addr, labels)
+	http.Handle(*metricsPath, promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		metricsHandler(manager, *timeoutOffset),
+	))
+	http.HandleFunc("/probe", func(w http.ResponseWriter, r *http.Request) {
+		probeHandler(w, r, manager.Config(), flagsModule(), *timeoutOffset, logger)
+	})
+	http.HandleFunc("/-/reload", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "This endpoint requires a POST request.", http.StatusMethodNotAllowed)
+			return
 		}
-	}
-
-	http.Handle(*metricsPath, promhttp.Handler())
+		if err := reload(manager, logger); err != nil {
+			http.Error(w, fmt.Sprintf("Reloading configuration failed: %v", err), http.StatusInternalServerError)
+		}
+	})
 
 	if *metricsPath != "/" && *metricsPath != "" {
 		landingConfig := web.LandingConfig{
@@ -198,6 +240,14 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill, syscall.SIGTERM)
 	defer cancel()
 
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			_ = reload(manager, logger)
+		}
+	}()
+
 	srv := &http.Server{
 		ReadHeaderTimeout: 5 * time.Second,
 	}
@@ -220,44 +270,384 @@ func main() {
 	_ = srv.Shutdown(srvCtx)
 }
 
-func registerCollector(logger log.Logger, transport *http.Transport,
-	addr string, labels map[string]string,
-) {
+// reload reloads the configuration of the manager and logs the outcome.
+func reload(manager *targetManager, logger log.Logger) error {
+	if err := manager.Reload(); err != nil {
+		level.Error(logger).Log("msg", "Reloading configuration failed", "error", err.Error())
+		return err
+	}
+	level.Info(logger).Log("msg", "Reloaded configuration")
+	return nil
+}
+
+// metricsHandler serves the metrics of the exporter and of every target. The
+// targets are scraped with the context returned by scrapeContext.
+func metricsHandler(manager *targetManager, offset time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel, err := scrapeContext(r, offset)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer cancel()
+
+		targets, err := manager.Gatherer(ctx)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Creating the scrape registry failed: %v", err), http.StatusInternalServerError)
+			return
+		}
+		h := promhttp.HandlerFor(prometheus.Gatherers{prometheus.DefaultGatherer, targets}, promhttp.HandlerOpts{})
+		h.ServeHTTP(w, r)
+	})
+}
+
+// scrapeContext returns the context to scrape the targets with for the
+// request r. When Prometheus sends its scrape timeout in the
+// X-Prometheus-Scrape-Timeout-Seconds header, the context expires offset
+// before it, so a slow NGINX is reported as down instead of failing the whole
+// scrape.
+func scrapeContext(r *http.Request, offset time.Duration) (context.Context, context.CancelFunc, error) {
+	header := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds")
+	if header == "" {
+		ctx, cancel := context.WithCancel(r.Context())
+		return ctx, cancel, nil
+	}
+
+	seconds, err := strconv.ParseFloat(header, 64)
+	if err != nil || math.IsNaN(seconds) || math.IsInf(seconds, 0) || seconds <= 0 {
+		return nil, nil, fmt.Errorf("invalid X-Prometheus-Scrape-Timeout-Seconds header %q", header)
+	}
+	timeout := time.Duration(seconds * float64(time.Second))
+	if timeout > offset {
+		timeout -= offset
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	return ctx, cancel, nil
+}
+
+// loadConfig reads the configuration file, if any, and returns it together
+// with the targets to scrape. Without targets in the file, the command-line
+// flags describe the targets.
+func loadConfig() (*config.Config, []config.Target, error) {
+	cfg := &config.Config{}
+	if *configFile != "" {
+		var err error
+		cfg, err = config.LoadFile(*configFile)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(cfg.Targets) > 0 {
+		return cfg, cfg.Targets, nil
+	}
+	if len(*scrapeURIs) == 0 {
+		return nil, nil, errors.New("no scrape addresses provided")
+	}
+	return cfg, flagsTargets(), nil
+}
+
+// targetLabels returns the const labels of the target. When several targets
+// are scraped, the scrape URI is added to tell their metrics apart.
+func targetLabels(target config.Target, multiple bool) map[string]string {
+	labels := collector.MergeLabels(constLabels, target.ConstLabels)
+	if multiple {
+		labels["addr"] = target.URI
+	}
+	return labels
+}
+
+// newCollector creates a collector for the scrape address addr according to the module.
+// The collector serves a single probe, so its transport does not keep idle
+// connections, which would stay open until they time out.
+func newCollector(logger log.Logger, addr string, module config.Module, labels map[string]string) (prometheus.Collector, error) {
+	transport, endpoint, err := newTransport(addr, module)
+	if err != nil {
+		return nil, err
+	}
+	transport.DisableKeepAlives = true
+	c, err := newClientCollector(logger, addr, endpoint, newHTTPClient(transport, module), module, nil, labels)
+	if err != nil {
+		return nil, err
+	}
+	return collector.NewScrapeCollector(c, namespaceOrDefault(module), module.Mode, addr, labels), nil
+}
+
+// newClientCollector creates a collector that scrapes endpoint, the URL of the
+// scrape address addr, with httpClient. When maxConnections is not nil, it
+// returns the maximum number of connections of the NGINX configuration of the
+// target, which the stub_status collector reports the utilization of.
+func newClientCollector(logger log.Logger, addr, endpoint string, httpClient *http.Client, module config.Module, maxConnections func() (float64, bool), labels map[string]string) (prometheus.Collector, error) {
+	switch module.Mode {
+	case config.ModeAuto:
+		detect := func(ctx context.Context) (client.API, error) {
+			return client.DetectAPI(ctx, httpClient, endpoint)
+		}
+		newCollector := func(api client.API) (prometheus.Collector, error) {
+			detected := module
+			detected.Mode = string(api)
+			return newClientCollector(logger, addr, endpoint, httpClient, detected, maxConnections, labels)
+		}
+		return collector.NewNginxAutoCollector(detect, newCollector, namespaceOrDefault(module), labels, logger), nil
+	case config.ModePlus:
+		plusClient := client.NewNginxPlusClient(httpClient, endpoint)
+		variableLabelNames := collector.NewVariableLabelNames(nil, nil, nil, nil, nil, nil, nil, nil)
+		return collector.NewNginxPlusContextCollector(plusClient, namespaceOrDefault(module), variableLabelNames, labels, logger), nil
+	case config.ModeAngie:
+		angieClient := client.NewAngieClient(httpClient, endpoint)
+		return collector.NewAngieCollector(angieClient, namespaceOrDefault(module), labels, logger), nil
+	case config.ModeVTS:
+		vtsClient := client.NewVTSClient(httpClient, endpoint)
+		return collector.NewVTSCollector(vtsClient, namespaceOrDefault(module), labels, logger), nil
+	case config.ModeReqStat:
+		reqStatClient := client.NewReqStatClient(httpClient, endpoint)
+		return collector.NewReqStatCollector(reqStatClient, namespaceOrDefault(module), labels, logger), nil
+	default:
+		ossClient := client.NewNginxClient(httpClient, endpoint)
+		return collector.NewNginxCollector(ossClient, addr, maxConnections, restarts, namespaceOrDefault(module), labels, logger), nil
+	}
+}
+
+// newPollingCollector wraps c in a collector polling it in the background, or
+// returns nil when the module does not poll its targets.
+func newPollingCollector(logger log.Logger, c prometheus.Collector, module config.Module, labels map[string]string) *collector.PollingCollector {
+	interval := module.PollInterval
+	if interval == 0 {
+		interval = *pollInterval
+	}
+	if interval == 0 {
+		return nil
+	}
+
+	maxAge := module.PollMaxAge
+	if maxAge == 0 {
+		maxAge = *pollMaxAge
+	}
+	if maxAge == 0 {
+		maxAge = 3 * interval
+	}
+
+	return collector.NewPollingCollector(c, interval, maxAge, namespaceOrDefault(module), labels, logger)
+}
+
+// newAccessLogCollector creates a collector which tails the files of the
+// access log and receives its syslog messages.
+func newAccessLogCollector(logger log.Logger, accessLog config.AccessLog, labels map[string]string) (*collector.AccessLogCollector, error) {
+	parser, err := accesslog.NewParser(accessLog.Format)
+	if err != nil {
+		return nil, err
+	}
+	routes := make([]accesslog.Route, 0, len(accessLog.Routes))
+	for _, route := range accessLog.Routes {
+		re, err := regexp.Compile(route.Match)
+		if err != nil {
+			return nil, fmt.Errorf("route %q: %w", route.Name, err)
+		}
+		routes = append(routes, accesslog.Route{Name: route.Name, Regexp: re})
+	}
+	sources := make([]collector.LineSource, 0, len(accessLog.Paths)+len(accessLog.Syslog))
+	for _, path := range accessLog.Paths {
+		sources = append(sources, tail.New(path, tailInterval, logger))
+	}
+	for _, addr := range accessLog.Syslog {
+		receiver, err := syslog.NewReceiver(addr, logger)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, receiver)
+	}
+
+	namespace := accessLog.Namespace
+	if namespace == "" {
+		namespace = "nginx"
+	}
+	histogramOpts := collector.HistogramOptions{
+		Buckets: accessLog.Histograms.Buckets,
+		Native:  accessLog.Histograms.Native,
+	}
+	return collector.NewAccessLogCollector(parser, sources, accessLog.Hosts, routes, histogramOpts, namespace, labels, logger), nil
+}
+
+// newErrorLogCollector creates a collector which tails the files of the
+// error log and receives its syslog messages.
+func newErrorLogCollector(logger log.Logger, errorLog config.ErrorLog, labels map[string]string) (*collector.ErrorLogCollector, error) {
+	categories := slices.Clone(errorlog.Categories)
+	for _, category := range errorLog.Categories {
+		re, err := regexp.Compile(category.Match)
+		if err != nil {
+			return nil, fmt.Errorf("category %q: %w", category.Name, err)
+		}
+		categories = append(categories, errorlog.Category{Name: category.Name, Regexp: re})
+	}
+	sources := make([]collector.LineSource, 0, len(errorLog.Paths)+len(errorLog.Syslog))
+	for _, path := range errorLog.Paths {
+		sources = append(sources, tail.New(path, tailInterval, logger))
+	}
+	for _, addr := range errorLog.Syslog {
+		receiver, err := syslog.NewReceiver(addr, logger)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, receiver)
+	}
+
+	namespace := errorLog.Namespace
+	if namespace == "" {
+		namespace = "nginx"
+	}
+	return collector.NewErrorLogCollector(sources, categories, namespace, labels, logger), nil
+}
+
+// errorLogLabels returns the const labels of the error log. When several
+// error logs are configured, the name is added to tell their metrics apart.
+func errorLogLabels(errorLog config.ErrorLog, multiple bool) map[string]string {
+	labels := collector.MergeLabels(constLabels, errorLog.ConstLabels)
+	if multiple {
+		labels["error_log"] = errorLog.Name
+	}
+	return labels
+}
+
+// accessLogLabels returns the const labels of the access log. When several
+// access logs are configured, the name is added to tell their metrics apart.
+func accessLogLabels(accessLog config.AccessLog, multiple bool) map[string]string {
+	labels := collector.MergeLabels(constLabels, accessLog.ConstLabels)
+	if multiple {
+		labels["access_log"] = accessLog.Name
+	}
+	return labels
+}
+
+// namespaceOrDefault returns the namespace of the module or the default
+// namespace of its mode.
+func namespaceOrDefault(module config.Module) string {
+	if module.Namespace != "" {
+		return module.Namespace
+	}
+	switch module.Mode {
+	case config.ModePlus:
+		return "nginxplus"
+	case config.ModeAngie:
+		return "angie"
+	default:
+		return "nginx"
+	}
+}
+
+// newTransport creates a transport for the scrape address addr. Every target
+// owns its transport, so targets never share a dialer, TLS config or connection
+// pool. It also returns the URL to request, which differs from addr for unix
+// domain sockets.
+func newTransport(addr string, module config.Module) (*http.Transport, string, error) {
+	tlsConfig, err := commoncfg.NewTLSConfig(&module.TLSConfig)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create TLS config: %w", err)
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   moduleTimeout(module),
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSClientConfig:     tlsConfig,
+		MaxIdleConnsPerHost: 1,
+		IdleConnTimeout:     90 * time.Second,
+	}
+
 	if strings.HasPrefix(addr, "unix:") {
 		socketPath, requestPath, err := parseUnixSocketAddress(addr)
 		if err != nil {
-			level.Error(logger).Log("msg", "Parsing unix domain socket scrape address failed", "uri", addr, "error", err.Error())
-			os.Exit(1)
+			return nil, "", fmt.Errorf("failed to parse unix domain socket scrape address %q: %w", addr, err)
 		}
 
-		transport.DialContext = func(_ context.Context, _, _ string) (net.Conn, error) {
-			return net.Dial("unix", socketPath)
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socketPath)
 		}
 		addr = "http://unix" + requestPath
 	}
 
-	userAgent := fmt.Sprintf("NGINX-Prometheus-Exporter/v%v", version.Version)
+	return transport, addr, nil
+}
 
-	httpClient := &http.Client{
-		Timeout: *timeout,
+// newHTTPClient creates an HTTP client that sends the module headers over rt.
+func newHTTPClient(rt http.RoundTripper, module config.Module) *http.Client {
+	if len(module.Headers) > 0 {
+		rt = &headerRoundTripper{
+			headers: module.Headers,
+			rt:      rt,
+		}
+	}
+
+	return &http.Client{
+		Timeout: moduleTimeout(module),
 		Transport: &userAgentRoundTripper{
-			agent: userAgent,
-			rt:    transport,
+			agent: fmt.Sprintf("NGINX-Prometheus-Exporter/v%v", version.Version),
+			rt:    rt,
 		},
 	}
+}
 
-	if *nginxPlus {
-		plusClient, err := plusclient.NewNginxClient(addr, plusclient.WithHTTPClient(httpClient))
-		if err != nil {
-			level.Error(logger).Log("msg", "Could not create Nginx Plus Client", "error", err.Error())
-			os.Exit(1)
+func moduleTimeout(module config.Module) time.Duration {
+	if module.Timeout != 0 {
+		return module.Timeout
+	}
+	return *timeout
+}
+
+type headerRoundTripper struct {
+	rt      http.RoundTripper
+	headers map[string]string
+}
+
+func (rt *headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = cloneRequest(req)
+	for name, value := range rt.headers {
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+			continue
 		}
-		variableLabelNames := collector.NewVariableLabelNames(nil, nil, nil, nil, nil, nil, nil, nil)
-		prometheus.MustRegister(collector.NewNginxPlusCollector(plusClient, "nginxplus", variableLabelNames, labels, logger))
-	} else {
-		ossClient := client.NewNginxClient(httpClient, addr)
-		prometheus.MustRegister(collector.NewNginxCollector(ossClient, "nginx", labels, logger))
+		req.Header.Set(name, value)
+	}
+	return rt.rt.RoundTrip(req)
+}
+
+// flagsTargets returns a target for every scrape URI passed on the command line.
+func flagsTargets() []config.Target {
+	targets := make([]config.Target, 0, len(*scrapeURIs))
+	for _, addr := range *scrapeURIs {
+		targets = append(targets, config.Target{
+			Name:   addr,
+			URI:    addr,
+			Module: flagsModule(),
+		})
+	}
+	return targets
+}
+
+// flagsModule returns the module described by the command-line flags.
+func flagsModule() config.Module {
+	module := config.Module{
+		Mode:    config.ModeOSS,
+		Timeout: *timeout,
+		TLSConfig: commoncfg.TLSConfig{
+			InsecureSkipVerify: !*sslVerify,
+			CAFile:             *sslCaCert,
+		},
+	}
+	if *nginxPlus {
+		module.Mode = config.ModePlus
+	}
+	if *nginxMode != "" {
+		module.Mode = *nginxMode
+	}
+	if *sslClientCert != "" && *sslClientKey != "" {
+		module.TLSConfig.CertFile = *sslClientCert
+		module.TLSConfig.KeyFile = *sslClientKey
 	}
+	return module
 }
 
 type userAgentRoundTripper struct {
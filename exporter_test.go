@@ -1,11 +1,19 @@
 package main
 
 import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/nginxinc/nginx-prometheus-exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/exporter-toolkit/web/kingpinflag"
 )
 
@@ -111,6 +119,83 @@ func TestParseUnixSocketAddress(t *testing.T) {
 	}
 }
 
+func TestScrapeContext(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		header       string
+		offset       time.Duration
+		wantDeadline bool
+		wantTimeout  time.Duration
+		wantErr      bool
+	}{
+		{
+			name: "no header",
+		},
+		{
+			name:         "offset subtracted",
+			header:       "10",
+			offset:       500 * time.Millisecond,
+			wantDeadline: true,
+			wantTimeout:  9500 * time.Millisecond,
+		},
+		{
+			name:         "offset larger than timeout",
+			header:       "0.25",
+			offset:       500 * time.Millisecond,
+			wantDeadline: true,
+			wantTimeout:  250 * time.Millisecond,
+		},
+		{
+			name:    "invalid header",
+			header:  "ten",
+			wantErr: true,
+		},
+		{
+			name:    "negative timeout",
+			header:  "-1",
+			wantErr: true,
+		},
+		{
+			name:    "infinite timeout",
+			header:  "+Inf",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", tt.header)
+			}
+			start := time.Now()
+			ctx, cancel, err := scrapeContext(req, tt.offset)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("scrapeContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer cancel()
+
+			deadline, ok := ctx.Deadline()
+			if ok != tt.wantDeadline {
+				t.Fatalf("scrapeContext() has deadline %v, want %v", ok, tt.wantDeadline)
+			}
+			if !ok {
+				return
+			}
+			if got := deadline.Sub(start); got < tt.wantTimeout || got > tt.wantTimeout+time.Second {
+				t.Errorf("scrapeContext() timeout = %v, want %v", got, tt.wantTimeout)
+			}
+		})
+	}
+}
+
 func TestAddMissingEnvironmentFlags(t *testing.T) {
 	expectedMatches := map[string]string{
 		"non-matching-flag":  "",
@@ -168,3 +253,65 @@ func TestConvertFlagToEnvar(t *testing.T) {
 		}
 	}
 }
+
+func TestNewCollectorMixedTargets(t *testing.T) {
+	t.Parallel()
+
+	newStubStatusServer := func(active int) *httptest.Server {
+		return httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprintf(w, "Active connections: %d \nserver accepts handled requests\n 1 1 1 \nReading: 0 Writing: 1 Waiting: 0 \n", active)
+		}))
+	}
+
+	dir := t.TempDir()
+	addrs := make([]string, 0, 3)
+	for i, active := range []int{10, 20} {
+		socketPath := filepath.Join(dir, fmt.Sprintf("nginx-%d.sock", i))
+		listener, err := net.Listen("unix", socketPath)
+		if err != nil {
+			t.Fatalf("failed to listen on unix socket: %v", err)
+		}
+		srv := newStubStatusServer(active)
+		srv.Listener = listener
+		srv.Start()
+		t.Cleanup(srv.Close)
+		addrs = append(addrs, "unix:"+socketPath+":/stub_status")
+	}
+	tcpServer := newStubStatusServer(30)
+	tcpServer.Start()
+	t.Cleanup(tcpServer.Close)
+	addrs = append(addrs, tcpServer.URL+"/stub_status")
+
+	registry := prometheus.NewRegistry()
+	for _, addr := range addrs {
+		c, err := newCollector(log.NewNopLogger(), addr, config.Module{Mode: config.ModeOSS}, map[string]string{"addr": addr})
+		if err != nil {
+			t.Fatalf("newCollector(%q) returned error: %v", addr, err)
+		}
+		registry.MustRegister(c)
+	}
+
+	metricFamilies, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+
+	got := make(map[string]float64)
+	for _, mf := range metricFamilies {
+		if mf.GetName() != "nginx_connections_active" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "addr" {
+					got[l.GetValue()] = m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+
+	want := map[string]float64{addrs[0]: 10, addrs[1]: 20, addrs[2]: 30}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nginx_connections_active = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,47 @@
+package nginxconf
+
+import "sync"
+
+// Cache keeps the configurations loaded from files, so a configuration read
+// by several collectors on every scrape is only parsed again after its files
+// change. The files and the directories of include patterns are checked by
+// their modification times and sizes.
+type Cache struct {
+	configs map[string]*Config
+	mutex   sync.Mutex
+}
+
+// NewCache creates an empty Cache.
+func NewCache() *Cache {
+	return &Cache{configs: make(map[string]*Config)}
+}
+
+// Load returns the configuration of the file at path like Load, parsing it
+// again only when any of its files changed since the last call. The returned
+// configuration is shared and must not be modified.
+func (c *Cache) Load(path string) (*Config, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if cfg, ok := c.configs[path]; ok && !cfg.changed() {
+		return cfg, nil
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		delete(c.configs, path)
+		return nil, err
+	}
+	c.configs[path] = cfg
+	return cfg, nil
+}
+
+// changed reports whether any file or include directory of the configuration
+// changed since it was loaded.
+func (cfg *Config) changed() bool {
+	for _, s := range cfg.sources {
+		if s.changed() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package nginxconf
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// CertificateDirectives are the directives whose files are reported as
+// certificates.
+var CertificateDirectives = []string{"ssl_certificate", "proxy_ssl_certificate"}
+
+// Certificate is a certificate file referenced by the configuration.
+type Certificate struct {
+	// Context is "http" or "stream".
+	Context string
+	// Directive is "ssl_certificate" or "proxy_ssl_certificate".
+	Directive string
+	// Path is the path of the file, resolved against the prefix of the
+	// configuration.
+	Path string
+	// ServerNames are the names of the server blocks using the file, sorted.
+	// A server block without names has the name "".
+	ServerNames []string
+}
+
+// Certificates returns the certificate files of the http and stream blocks of
+// the configuration, once per context and directive, in order.
+//
+// Like NGINX, the server blocks without a directive inherit the directive of
+// the enclosing block. The proxy_ssl_certificate files of the locations of a
+// server block are used by the server block. Paths with variables, which are
+// only known per request, and data: and engine: certificates are skipped.
+func Certificates(cfg *Config) []Certificate {
+	var certificates []Certificate
+	index := make(map[[3]string]int)
+	add := func(context, directive, path string, serverNames []string) {
+		key := [3]string{context, directive, path}
+		i, ok := index[key]
+		if !ok {
+			i = len(certificates)
+			index[key] = i
+			certificates = append(certificates, Certificate{Context: context, Directive: directive, Path: path})
+		}
+		for _, name := range serverNames {
+			if !slices.Contains(certificates[i].ServerNames, name) {
+				certificates[i].ServerNames = append(certificates[i].ServerNames, name)
+			}
+		}
+	}
+
+	for _, block := range cfg.Directives {
+		if (block.Name != "http" && block.Name != "stream") || block.Block == nil {
+			continue
+		}
+		for _, directive := range CertificateDirectives {
+			inherited, _ := certificatePaths(cfg.Prefix, block.Block, directive, false)
+			for _, path := range inherited {
+				add(block.Name, directive, path, nil)
+			}
+			for _, server := range block.Block {
+				if server.Name != "server" || server.Block == nil {
+					continue
+				}
+				// Only the proxy directives apply to the locations.
+				paths, found := certificatePaths(cfg.Prefix, server.Block, directive, directive != "ssl_certificate")
+				if !found {
+					paths = inherited
+				}
+				for _, path := range paths {
+					add(block.Name, directive, path, serverNames(server))
+				}
+			}
+		}
+	}
+
+	for i := range certificates {
+		slices.Sort(certificates[i].ServerNames)
+	}
+	return certificates
+}
+
+// certificatePaths returns the resolved paths of the directives named name in
+// the block and, with nested, in the blocks it contains, and whether any
+// directive was found, even with a skipped path.
+func certificatePaths(prefix string, block []*Directive, name string, nested bool) ([]string, bool) {
+	var paths []string
+	var found bool
+	for _, d := range block {
+		if nested && d.Block != nil {
+			nestedPaths, nestedFound := certificatePaths(prefix, d.Block, name, nested)
+			paths = append(paths, nestedPaths...)
+			found = found || nestedFound
+			continue
+		}
+		if d.Name != name || len(d.Args) != 1 {
+			continue
+		}
+		found = true
+		path := d.Args[0]
+		if strings.Contains(path, "$") || strings.HasPrefix(path, "data:") || strings.HasPrefix(path, "engine:") {
+			continue
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(prefix, path)
+		}
+		if !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths, found
+}
+
+// serverNames returns the names of a server block, or "" for a block without
+// names.
+func serverNames(server *Directive) []string {
+	var names []string
+	for _, d := range server.Block {
+		if d.Name == "server_name" {
+			names = append(names, d.Args...)
+		}
+	}
+	if len(names) == 0 {
+		return []string{""}
+	}
+	return names
+}
@@ -0,0 +1,387 @@
+// Package nginxconf parses NGINX configuration files.
+package nginxconf
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxIncludeDepth limits nested includes, which also stops include cycles.
+const maxIncludeDepth = 32
+
+// Directive is a directive of the configuration, with its block when it has
+// one. The directives of included files replace the include directive.
+type Directive struct {
+	Name  string
+	File  string
+	Args  []string
+	Block []*Directive
+	Line  int
+}
+
+// Config is a parsed configuration.
+type Config struct {
+	// Files are the files read, starting with the main file.
+	Files []string
+	// Prefix is the directory relative paths of the configuration are
+	// resolved against.
+	Prefix string
+	// Hash is the hex encoded SHA-256 hash of the paths and contents of the
+	// files, in order.
+	Hash string
+	// ModTime is the newest modification time of the files.
+	ModTime    time.Time
+	Directives []*Directive
+	// sources are the files and the directories of include patterns as
+	// they were before reading them, to tell whether the configuration
+	// changed.
+	sources []source
+}
+
+// source is a file or directory the configuration depends on.
+type source struct {
+	modTime time.Time
+	path    string
+	size    int64
+	missing bool
+}
+
+// changed reports whether the file or directory was modified, created or
+// removed.
+func (s source) changed() bool {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return !s.missing
+	}
+	return s.missing || !info.ModTime().Equal(s.modTime) || info.Size() != s.size
+}
+
+// Load parses the configuration file at path and the files it includes.
+// Relative include paths are resolved against the directory of the file at
+// path, as NGINX does with its configuration prefix.
+func Load(path string) (*Config, error) {
+	l := &loader{
+		prefix: filepath.Dir(path),
+		config: &Config{Prefix: filepath.Dir(path)},
+		hash:   sha256.New(),
+	}
+	directives, err := l.load(path, 0)
+	if err != nil {
+		return nil, err
+	}
+	l.config.Directives = directives
+	l.config.Hash = hex.EncodeToString(l.hash.Sum(nil))
+	return l.config, nil
+}
+
+type loader struct {
+	config *Config
+	hash   hash.Hash
+	prefix string
+}
+
+func (l *loader) load(path string, depth int) ([]*Directive, error) {
+	if depth > maxIncludeDepth {
+		return nil, fmt.Errorf("%s: too many nested includes", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	l.config.Files = append(l.config.Files, path)
+	l.config.sources = append(l.config.sources, source{path: path, modTime: info.ModTime(), size: info.Size()})
+	if info.ModTime().After(l.config.ModTime) {
+		l.config.ModTime = info.ModTime()
+	}
+
+	// The files are hashed with their paths, so moving a directive to
+	// another file changes the hash.
+	fmt.Fprintf(l.hash, "%s\x00", path)
+	directives, err := Parse(io.TeeReader(f, l.hash), path)
+	if err != nil {
+		return nil, err
+	}
+	return l.resolveIncludes(directives, depth)
+}
+
+func (l *loader) resolveIncludes(directives []*Directive, depth int) ([]*Directive, error) {
+	result := make([]*Directive, 0, len(directives))
+	for _, d := range directives {
+		if d.Name != "include" || d.Block != nil {
+			if d.Block != nil {
+				block, err := l.resolveIncludes(d.Block, depth)
+				if err != nil {
+					return nil, err
+				}
+				d.Block = block
+			}
+			result = append(result, d)
+			continue
+		}
+
+		if len(d.Args) != 1 {
+			return nil, fmt.Errorf("%s:%d: include takes one argument", d.File, d.Line)
+		}
+		pattern := d.Args[0]
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(l.prefix, pattern)
+		}
+		paths := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") {
+			// A file added to the directory changes its modification
+			// time, so it is tracked like the files. A directory with
+			// patterns cannot be tracked and is always deemed changed.
+			dir := filepath.Dir(pattern)
+			if info, err := os.Stat(dir); err == nil {
+				l.config.sources = append(l.config.sources, source{path: dir, modTime: info.ModTime(), size: info.Size()})
+			} else {
+				l.config.sources = append(l.config.sources, source{path: dir, missing: !strings.ContainsAny(dir, "*?[")})
+			}
+			var err error
+			// Like NGINX, a pattern without matches is not an error.
+			paths, err = filepath.Glob(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid include pattern: %w", d.File, d.Line, err)
+			}
+		}
+		for _, path := range paths {
+			included, err := l.load(path, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, included...)
+		}
+	}
+	return result, nil
+}
+
+// Parse parses the directives of a configuration file, without resolving its
+// includes. The file name is only used in errors and in the directives.
+func Parse(r io.Reader, file string) ([]*Directive, error) {
+	p := &parser{
+		lexer: &lexer{reader: bufio.NewReader(r), line: 1},
+		file:  file,
+	}
+	directives, err := p.parseBlock(false)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%d: %w", file, p.lexer.line, err)
+	}
+	return directives, nil
+}
+
+type parser struct {
+	lexer *lexer
+	file  string
+}
+
+// parseBlock parses directives up to the end of the block or, at the top
+// level, the end of the file.
+func (p *parser) parseBlock(inBlock bool) ([]*Directive, error) {
+	var directives []*Directive
+	var current *Directive
+	for {
+		tok, err := p.lexer.next()
+		if errors.Is(err, io.EOF) {
+			if current != nil {
+				return nil, errors.New("unexpected end of file, expecting \";\" or \"}\"")
+			}
+			if inBlock {
+				return nil, errors.New("unexpected end of file, expecting \"}\"")
+			}
+			return directives, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case tok.special && tok.value == ";":
+			if current == nil {
+				return nil, errors.New("unexpected \";\"")
+			}
+			directives = append(directives, current)
+			current = nil
+		case tok.special && tok.value == "{":
+			if current == nil {
+				return nil, errors.New("unexpected \"{\"")
+			}
+			block, err := p.parseBlock(true)
+			if err != nil {
+				return nil, err
+			}
+			if block == nil {
+				block = []*Directive{}
+			}
+			current.Block = block
+			directives = append(directives, current)
+			current = nil
+		case tok.special && tok.value == "}":
+			if current != nil {
+				return nil, errors.New("unexpected \"}\", expecting \";\"")
+			}
+			if !inBlock {
+				return nil, errors.New("unexpected \"}\"")
+			}
+			return directives, nil
+		case current == nil:
+			current = &Directive{Name: tok.value, File: p.file, Line: tok.line}
+		default:
+			current.Args = append(current.Args, tok.value)
+		}
+	}
+}
+
+type token struct {
+	value string
+	line  int
+	// special is set for unquoted ";", "{" and "}".
+	special bool
+}
+
+type lexer struct {
+	reader *bufio.Reader
+	line   int
+}
+
+func (l *lexer) read() (rune, error) {
+	r, _, err := l.reader.ReadRune()
+	if r == '\n' {
+		l.line++
+	}
+	return r, err
+}
+
+func (l *lexer) unread() {
+	_ = l.reader.UnreadRune()
+}
+
+// next returns the next token, skipping whitespace and comments.
+func (l *lexer) next() (token, error) {
+	var r rune
+	var err error
+	for {
+		r, err = l.read()
+		if err != nil {
+			return token{}, err
+		}
+		if r == '#' {
+			if _, err := l.reader.ReadString('\n'); err != nil {
+				return token{}, err
+			}
+			l.line++
+			continue
+		}
+		if !isSpace(r) {
+			break
+		}
+	}
+
+	line := l.line
+	switch r {
+	case ';', '{', '}':
+		return token{value: string(r), line: line, special: true}, nil
+	case '"', '\'':
+		value, err := l.readQuoted(r)
+		return token{value: value, line: line}, err
+	}
+
+	var b strings.Builder
+	for {
+		switch {
+		case r == '\\':
+			b.WriteRune(r)
+			if r, err = l.read(); err != nil {
+				return token{}, err
+			}
+			b.WriteRune(r)
+		case r == '$':
+			b.WriteRune(r)
+			// "${name}" is a variable, not a block.
+			if r, err = l.read(); err != nil {
+				return token{value: b.String(), line: line}, nil
+			}
+			if r != '{' {
+				continue
+			}
+			for r != '}' {
+				b.WriteRune(r)
+				if r, err = l.read(); err != nil {
+					return token{}, err
+				}
+			}
+			b.WriteRune(r)
+		case isSpace(r) || r == ';' || r == '{' || r == '}':
+			l.unread()
+			if r == '\n' {
+				l.line--
+			}
+			return token{value: b.String(), line: line}, nil
+		default:
+			b.WriteRune(r)
+		}
+		if r, err = l.read(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return token{value: b.String(), line: line}, nil
+			}
+			return token{}, err
+		}
+	}
+}
+
+// readQuoted reads a string quoted with quote, whose opening quote was read.
+func (l *lexer) readQuoted(quote rune) (string, error) {
+	var b strings.Builder
+	for {
+		r, err := l.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return "", errors.New("unterminated quoted string")
+			}
+			return "", err
+		}
+		switch r {
+		case quote:
+			return b.String(), nil
+		case '\\':
+			next, err := l.read()
+			if err != nil {
+				return "", errors.New("unterminated quoted string")
+			}
+			// NGINX unescapes only the quotes, "\\" and the line ending
+			// escapes in quoted strings.
+			switch next {
+			case '"', '\'', '\\':
+				b.WriteRune(next)
+			case 'n':
+				b.WriteRune('\n')
+			case 'r':
+				b.WriteRune('\r')
+			case 't':
+				b.WriteRune('\t')
+			default:
+				b.WriteRune('\\')
+				b.WriteRune(next)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\r' || r == '\n'
+}
@@ -0,0 +1,337 @@
+package nginxconf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []*Directive
+		wantErr bool
+	}{
+		{
+			name:  "directives and blocks",
+			input: "worker_processes 2;\nevents {\n  worker_connections 512;\n}\n",
+			want: []*Directive{
+				{Name: "worker_processes", Args: []string{"2"}, File: "test.conf", Line: 1},
+				{Name: "events", File: "test.conf", Line: 2, Block: []*Directive{
+					{Name: "worker_connections", Args: []string{"512"}, File: "test.conf", Line: 3},
+				}},
+			},
+		},
+		{
+			name:  "quotes, escapes and comments",
+			input: `add_header X-Test "a \"b\" c" always; # comment` + "\n" + `return 200 'it\'s';`,
+			want: []*Directive{
+				{Name: "add_header", Args: []string{"X-Test", `a "b" c`, "always"}, File: "test.conf", Line: 1},
+				{Name: "return", Args: []string{"200", "it's"}, File: "test.conf", Line: 2},
+			},
+		},
+		{
+			name:  "variables with braces",
+			input: `set $path "${uri}"; set $x ${host}x;`,
+			want: []*Directive{
+				{Name: "set", Args: []string{"$path", "${uri}"}, File: "test.conf", Line: 1},
+				{Name: "set", Args: []string{"$x", "${host}x"}, File: "test.conf", Line: 1},
+			},
+		},
+		{
+			name:  "empty block",
+			input: "events {}",
+			want:  []*Directive{{Name: "events", Block: []*Directive{}, File: "test.conf", Line: 1}},
+		},
+		{
+			name:    "missing semicolon",
+			input:   "worker_processes 2",
+			wantErr: true,
+		},
+		{
+			name:    "unclosed block",
+			input:   "http {",
+			wantErr: true,
+		},
+		{
+			name:    "unexpected brace",
+			input:   "}",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated quote",
+			input:   `return 200 "ok;`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Parse(strings.NewReader(tt.input), "test.conf")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %s, want %s", format(got), format(tt.want))
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := Load("testdata/nginx.conf")
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+
+	wantFiles := []string{
+		"testdata/nginx.conf",
+		filepath.Join("testdata", "mime.types"),
+		filepath.Join("testdata", "conf.d", "api.conf"),
+		filepath.Join("testdata", "conf.d", "www.conf"),
+		filepath.Join("testdata", "stream.d", "dns.conf"),
+	}
+	if !reflect.DeepEqual(cfg.Files, wantFiles) {
+		t.Errorf("Load() read %v, want %v", cfg.Files, wantFiles)
+	}
+
+	s := Summarize(cfg)
+	if s.WorkerProcesses != 4 || s.WorkerConnections != 1024 || s.MaxConnections() != 4096 {
+		t.Errorf("Summarize() workers = %d x %d, want 4 x 1024", s.WorkerProcesses, s.WorkerConnections)
+	}
+	if want := map[string]int{"http": 2, "stream": 1}; !reflect.DeepEqual(s.Servers, want) {
+		t.Errorf("Summarize() servers = %v, want %v", s.Servers, want)
+	}
+	wantNames := []ServerName{
+		{Context: "http", Name: "api.example.com"},
+		{Context: "http", Name: "example.com"},
+		{Context: "http", Name: "www.example.com"},
+	}
+	if !reflect.DeepEqual(s.ServerNames, wantNames) {
+		t.Errorf("Summarize() server names = %v, want %v", s.ServerNames, wantNames)
+	}
+	wantUpstreams := []Upstream{
+		{Context: "http", Name: "backend", Servers: 3},
+		{Context: "stream", Name: "dns", Servers: 2},
+	}
+	if !reflect.DeepEqual(s.Upstreams, wantUpstreams) {
+		t.Errorf("Summarize() upstreams = %v, want %v", s.Upstreams, wantUpstreams)
+	}
+	wantListens := []Listen{
+		{Context: "http", Address: "443", SSL: true},
+		{Context: "http", Address: "[::]:443", SSL: true},
+		{Context: "http", Address: "80"},
+		{Context: "stream", Address: "53"},
+	}
+	if !reflect.DeepEqual(s.Listens, wantListens) {
+		t.Errorf("Summarize() listens = %v, want %v", s.Listens, wantListens)
+	}
+}
+
+func TestLoadMissingInclude(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nginx.conf")
+	writeFile(t, path, "include missing.conf;\ninclude optional/*.conf;\n")
+	if _, err := Load(path); err == nil {
+		t.Errorf("Load() returned no error for a missing include")
+	}
+
+	writeFile(t, path, "include optional/*.conf;\n")
+	if _, err := Load(path); err != nil {
+		t.Errorf("Load() returned error for a pattern without matches: %v", err)
+	}
+
+	writeFile(t, path, "include nginx.conf;\n")
+	if _, err := Load(path); err == nil {
+		t.Errorf("Load() returned no error for an include cycle")
+	}
+}
+
+func TestLoadHashAndModTime(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nginx.conf")
+	included := filepath.Join(dir, "included.conf")
+	writeFile(t, path, "include included.conf;\n")
+	writeFile(t, included, "worker_processes 2;\n")
+	older, newer := time.Unix(1700000000, 0), time.Unix(1700000100, 0)
+	for file, mtime := range map[string]time.Time{path: older, included: newer} {
+		if err := os.Chtimes(file, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if !cfg.ModTime.Equal(newer) {
+		t.Errorf("Load() modification time = %v, want %v", cfg.ModTime, newer)
+	}
+	if len(cfg.Hash) != 64 {
+		t.Errorf("Load() hash = %q, want a hex encoded SHA-256 hash", cfg.Hash)
+	}
+
+	writeFile(t, included, "worker_processes 4;\n")
+	changed, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if changed.Hash == cfg.Hash {
+		t.Errorf("Load() returned the same hash after an included file changed")
+	}
+}
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nginx.conf")
+	included := filepath.Join(dir, "conf.d", "www.conf")
+	if err := os.Mkdir(filepath.Join(dir, "conf.d"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, path, "worker_processes auto;\ninclude conf.d/*.conf;\n")
+	writeFile(t, included, "events {}\n")
+	older := time.Unix(1700000000, 0)
+	for _, file := range []string{path, included, filepath.Dir(included)} {
+		if err := os.Chtimes(file, older, older); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := NewCache()
+	cfg, err := c.Load(path)
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if s := Summarize(cfg); s.WorkerProcesses != 0 || s.MaxConnections() != 0 {
+		t.Errorf("Summarize() workers = %d, max connections = %d, want 0 for auto", s.WorkerProcesses, s.MaxConnections())
+	}
+	if cached, err := c.Load(path); err != nil || cached != cfg {
+		t.Errorf("Load() parsed the unchanged configuration again")
+	}
+
+	steps := []struct {
+		change func()
+		name   string
+	}{
+		{
+			name:   "included file changed",
+			change: func() { writeFile(t, included, "events {\n    worker_connections 100;\n}\n") },
+		},
+		{
+			name:   "file added to an include directory",
+			change: func() { writeFile(t, filepath.Join(dir, "conf.d", "api.conf"), "") },
+		},
+		{
+			name: "file removed",
+			change: func() {
+				if err := os.Remove(included); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+	for _, step := range steps {
+		step.change()
+		changed, err := c.Load(path)
+		if err != nil {
+			t.Fatalf("%s: Load() returned error: %v", step.name, err)
+		}
+		if changed == cfg {
+			t.Errorf("%s: Load() returned the cached configuration", step.name)
+		}
+		cfg = changed
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Load(path); err == nil {
+		t.Errorf("Load() returned no error for a removed configuration file")
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func format(directives []*Directive) string {
+	var b strings.Builder
+	for _, d := range directives {
+		b.WriteString(d.Name)
+		for _, arg := range d.Args {
+			b.WriteString(" " + arg)
+		}
+		if d.Block != nil {
+			b.WriteString(" {" + format(d.Block) + "}")
+		}
+		b.WriteString("; ")
+	}
+	return b.String()
+}
+
+func TestCertificates(t *testing.T) {
+	t.Parallel()
+
+	directives, err := Parse(strings.NewReader(`
+http {
+    ssl_certificate default.pem;
+    proxy_ssl_certificate /etc/nginx/client.pem;
+
+    server {
+        server_name example.com www.example.com;
+        ssl_certificate example.com.rsa.pem;
+        ssl_certificate example.com.ecdsa.pem;
+    }
+    server {
+        server_name api.example.com;
+        location / {
+            proxy_ssl_certificate /etc/nginx/api-client.pem;
+        }
+    }
+    server {
+        ssl_certificate $ssl_server_name.pem;
+    }
+}
+stream {
+    server {
+        ssl_certificate /etc/nginx/dns.pem;
+    }
+}
+`), "nginx.conf")
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	got := Certificates(&Config{Prefix: "/etc/nginx", Directives: directives})
+	want := []Certificate{
+		{Context: "http", Directive: "ssl_certificate", Path: "/etc/nginx/default.pem", ServerNames: []string{"api.example.com"}},
+		{Context: "http", Directive: "ssl_certificate", Path: "/etc/nginx/example.com.rsa.pem", ServerNames: []string{"example.com", "www.example.com"}},
+		{Context: "http", Directive: "ssl_certificate", Path: "/etc/nginx/example.com.ecdsa.pem", ServerNames: []string{"example.com", "www.example.com"}},
+		{Context: "http", Directive: "proxy_ssl_certificate", Path: "/etc/nginx/client.pem", ServerNames: []string{"", "example.com", "www.example.com"}},
+		{Context: "http", Directive: "proxy_ssl_certificate", Path: "/etc/nginx/api-client.pem", ServerNames: []string{"api.example.com"}},
+		{Context: "stream", Directive: "ssl_certificate", Path: "/etc/nginx/dns.pem", ServerNames: []string{""}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Certificates() = %+v, want %+v", got, want)
+	}
+}
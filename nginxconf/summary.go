@@ -0,0 +1,147 @@
+package nginxconf
+
+import (
+	"slices"
+	"strconv"
+)
+
+// Defaults of NGINX for the directives missing from the configuration.
+const (
+	defaultWorkerProcesses   = 1
+	defaultWorkerConnections = 512
+)
+
+// Walk calls fn for every directive, including those of blocks, in order.
+// The parents are the directives whose blocks contain the directive, from the
+// outermost.
+func Walk(directives []*Directive, fn func(d *Directive, parents []*Directive)) {
+	walk(directives, nil, fn)
+}
+
+func walk(directives []*Directive, parents []*Directive, fn func(d *Directive, parents []*Directive)) {
+	for _, d := range directives {
+		fn(d, parents)
+		if d.Block != nil {
+			walk(d.Block, append(slices.Clip(parents), d), fn)
+		}
+	}
+}
+
+// Summary describes the settings of a configuration the exporter reports.
+type Summary struct {
+	// Servers is the number of server blocks by context, "http" or
+	// "stream".
+	Servers     map[string]int
+	ServerNames []ServerName
+	Upstreams   []Upstream
+	Listens     []Listen
+	// WorkerProcesses is the number of worker processes, 0 for "auto" as
+	// NGINX then starts one per CPU of its host.
+	WorkerProcesses   int
+	WorkerConnections int
+}
+
+// ServerName is a name of a server block.
+type ServerName struct {
+	Context string
+	Name    string
+}
+
+// Upstream is an upstream block with the number of its servers.
+type Upstream struct {
+	Context string
+	Name    string
+	Servers int
+}
+
+// Listen is an address a server block listens on.
+type Listen struct {
+	Context string
+	Address string
+	SSL     bool
+}
+
+// MaxConnections returns the maximum number of connections NGINX accepts,
+// which is the product of the worker processes and connections, or 0 when the
+// worker processes are "auto".
+func (s Summary) MaxConnections() int {
+	return s.WorkerProcesses * s.WorkerConnections
+}
+
+// Summarize returns the summary of the configuration. The server names and
+// the listen addresses are listed once per context, in order.
+func Summarize(cfg *Config) Summary {
+	s := Summary{
+		Servers:           map[string]int{"http": 0, "stream": 0},
+		WorkerProcesses:   defaultWorkerProcesses,
+		WorkerConnections: defaultWorkerConnections,
+	}
+	seenNames := make(map[ServerName]bool)
+	seenListens := make(map[Listen]bool)
+
+	Walk(cfg.Directives, func(d *Directive, parents []*Directive) {
+		context := blockContext(parents)
+		switch {
+		case d.Name == "worker_processes" && len(parents) == 0 && len(d.Args) == 1:
+			if d.Args[0] == "auto" {
+				s.WorkerProcesses = 0
+			} else if n, err := strconv.Atoi(d.Args[0]); err == nil {
+				s.WorkerProcesses = n
+			}
+		case d.Name == "worker_connections" && isIn(parents, "events") && len(d.Args) == 1:
+			if n, err := strconv.Atoi(d.Args[0]); err == nil {
+				s.WorkerConnections = n
+			}
+		case d.Name == "server" && d.Block != nil && context != "" && parentName(parents) == context:
+			s.Servers[context]++
+		case d.Name == "server_name" && parentName(parents) == "server":
+			for _, name := range d.Args {
+				n := ServerName{Context: context, Name: name}
+				if !seenNames[n] {
+					seenNames[n] = true
+					s.ServerNames = append(s.ServerNames, n)
+				}
+			}
+		case d.Name == "listen" && parentName(parents) == "server" && len(d.Args) > 0:
+			l := Listen{Context: context, Address: d.Args[0], SSL: slices.Contains(d.Args[1:], "ssl")}
+			if !seenListens[l] {
+				seenListens[l] = true
+				s.Listens = append(s.Listens, l)
+			}
+		case d.Name == "upstream" && d.Block != nil && len(d.Args) == 1:
+			u := Upstream{Context: context, Name: d.Args[0]}
+			for _, server := range d.Block {
+				if server.Name == "server" && server.Block == nil {
+					u.Servers++
+				}
+			}
+			s.Upstreams = append(s.Upstreams, u)
+		}
+	})
+	return s
+}
+
+// blockContext returns "http" or "stream" for a directive in the block of
+// either, or an empty string.
+func blockContext(parents []*Directive) string {
+	if len(parents) > 0 {
+		switch parents[0].Name {
+		case "http", "stream":
+			return parents[0].Name
+		}
+	}
+	return ""
+}
+
+func parentName(parents []*Directive) string {
+	if len(parents) == 0 {
+		return ""
+	}
+	return parents[len(parents)-1].Name
+}
+
+func isIn(parents []*Directive, name string) bool {
+	return slices.ContainsFunc(parents, func(d *Directive) bool {
+		return d.Name == name
+	})
+}
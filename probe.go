@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/nginxinc/nginx-prometheus-exporter/collector"
+	"github.com/nginxinc/nginx-prometheus-exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// probeHandler scrapes the NGINX instance given by the target query parameter
+// and responds with its metrics only. The module query parameter selects the
+// module from the configuration file; without it the command-line flags are used.
+// The scrape is bounded by the scrape timeout of Prometheus minus offset.
+func probeHandler(w http.ResponseWriter, r *http.Request, cfg *config.Config, defaultModule config.Module, offset time.Duration, logger log.Logger) {
+	params := r.URL.Query()
+
+	target := params.Get("target")
+	if target == "" {
+		http.Error(w, "Target parameter is missing", http.StatusBadRequest)
+		return
+	}
+
+	module := defaultModule
+	moduleName := params.Get("module")
+	if moduleName != "" {
+		m, ok := cfg.Modules[moduleName]
+		if !ok {
+			http.Error(w, fmt.Sprintf("Unknown module %q", moduleName), http.StatusBadRequest)
+			return
+		}
+		module = m
+	}
+
+	ctx, cancel, err := scrapeContext(r, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer cancel()
+
+	logger = log.With(logger, "module", moduleName, "target", target)
+
+	c, err := newCollector(logger, target, module, constLabels)
+	if err != nil {
+		level.Error(logger).Log("msg", "Creating collector failed", "error", err.Error())
+		http.Error(w, fmt.Sprintf("Creating collector failed: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(collector.WithContext(ctx, c)); err != nil {
+		level.Error(logger).Log("msg", "Registering collector failed", "error", err.Error())
+		http.Error(w, fmt.Sprintf("Registering collector failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/nginxinc/nginx-prometheus-exporter/config"
+)
+
+const stubStatus = "Active connections: 1457 \nserver accepts handled requests\n 6717066 6717066 65844359 \nReading: 1 Writing: 8 Waiting: 1448 \n"
+
+func TestProbeHandler(t *testing.T) {
+	t.Parallel()
+
+	nginx := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}
+		if r.Header.Get("X-Probe") != "yes" {
+			http.Error(w, "missing header", http.StatusForbidden)
+			return
+		}
+		_, _ = io.WriteString(w, stubStatus)
+	}))
+	t.Cleanup(nginx.Close)
+
+	cfg := &config.Config{
+		Modules: map[string]config.Module{
+			"headers": {Mode: config.ModeOSS, Headers: map[string]string{"X-Probe": "yes"}},
+			"auto":    {Mode: config.ModeAuto, Headers: map[string]string{"X-Probe": "yes"}},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		query      url.Values
+		header     http.Header
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "missing target",
+			query:      url.Values{},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown module",
+			query:      url.Values{"target": {nginx.URL}, "module": {"unknown"}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "module headers are sent",
+			query:      url.Values{"target": {nginx.URL}, "module": {"headers"}},
+			wantStatus: http.StatusOK,
+			wantBody:   "nginx_connections_active 1457",
+		},
+		{
+			name:       "auto module detects stub_status",
+			query:      url.Values{"target": {nginx.URL}, "module": {"auto"}},
+			wantStatus: http.StatusOK,
+			wantBody:   `nginx_exporter_detected_mode_info{mode="oss"} 1`,
+		},
+		{
+			name:       "auto module reports its mode",
+			query:      url.Values{"target": {nginx.URL}, "module": {"auto"}},
+			wantStatus: http.StatusOK,
+			wantBody:   `nginx_target_info{mode="auto"`,
+		},
+		{
+			name:       "default module reports failed scrape",
+			query:      url.Values{"target": {nginx.URL}},
+			wantStatus: http.StatusOK,
+			wantBody:   "nginx_up 0",
+		},
+		{
+			name:       "scrape timeout cancels slow target",
+			query:      url.Values{"target": {nginx.URL + "/slow"}, "module": {"headers"}},
+			header:     http.Header{"X-Prometheus-Scrape-Timeout-Seconds": {"0.6"}},
+			wantStatus: http.StatusOK,
+			wantBody:   "nginx_up 0",
+		},
+		{
+			name:       "invalid scrape timeout",
+			query:      url.Values{"target": {nginx.URL}, "module": {"headers"}},
+			header:     http.Header{"X-Prometheus-Scrape-Timeout-Seconds": {"soon"}},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/probe?"+tt.query.Encode(), nil)
+			for name, values := range tt.header {
+				req.Header[name] = values
+			}
+			rec := httptest.NewRecorder()
+			start := time.Now()
+			probeHandler(rec, req, cfg, config.Module{Mode: config.ModeOSS}, 500*time.Millisecond, log.NewNopLogger())
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("probeHandler() returned after %v, want the scrape timeout to cancel the scrape", elapsed)
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("probeHandler() status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("probeHandler() body does not contain %q:\n%s", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestProbeHandlerClosesConnections(t *testing.T) {
+	t.Parallel()
+
+	var open atomic.Int64
+	nginx := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, stubStatus)
+	}))
+	nginx.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			open.Add(1)
+		case http.StateClosed, http.StateHijacked:
+			open.Add(-1)
+		}
+	}
+	nginx.Start()
+	t.Cleanup(nginx.Close)
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/probe?"+url.Values{"target": {nginx.URL}}.Encode(), nil)
+		probeHandler(httptest.NewRecorder(), req, &config.Config{}, config.Module{Mode: config.ModeOSS}, 500*time.Millisecond, log.NewNopLogger())
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for open.Load() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d connections to the target are still open after the probes", open.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestProbeHandlerDetectsRestarts(t *testing.T) {
+	t.Parallel()
+
+	// The counters of the second probe are lower, as after a restart.
+	pages := []string{
+		stubStatus,
+		"Active connections: 1 \nserver accepts handled requests\n 10 10 20 \nReading: 0 Writing: 1 Waiting: 0 \n",
+	}
+	var probe atomic.Int64
+	nginx := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, pages[probe.Load()])
+	}))
+	t.Cleanup(nginx.Close)
+
+	wantRestarts := []string{"nginx_restarts_detected_total 0", "nginx_restarts_detected_total 1"}
+	for i, want := range wantRestarts {
+		probe.Store(int64(i))
+		req := httptest.NewRequest(http.MethodGet, "/probe?"+url.Values{"target": {nginx.URL}}.Encode(), nil)
+		rec := httptest.NewRecorder()
+		probeHandler(rec, req, &config.Config{}, config.Module{Mode: config.ModeOSS}, 500*time.Millisecond, log.NewNopLogger())
+
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("probe %d: probeHandler() body does not contain %q:\n%s", i, want, rec.Body.String())
+		}
+	}
+}
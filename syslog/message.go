@@ -0,0 +1,112 @@
+// Package syslog receives NGINX log lines sent as syslog messages.
+package syslog
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrMalformed means a message is neither an RFC 3164 nor an RFC 5424 syslog
+// message. It is wrapped with the reason.
+var ErrMalformed = errors.New("malformed syslog message")
+
+// rfc3164Timestamp is the timestamp layout of RFC 3164 messages.
+const rfc3164Timestamp = time.Stamp
+
+// ParseMessage returns the content of an RFC 3164 or RFC 5424 syslog message,
+// without the header and the trailing line ending.
+func ParseMessage(message string) (string, error) {
+	message = strings.TrimRight(message, "\r\n\x00")
+
+	if !strings.HasPrefix(message, "<") {
+		return "", fmt.Errorf("%w: missing priority", ErrMalformed)
+	}
+	end := strings.IndexByte(message, '>')
+	if end < 2 || end > 4 {
+		return "", fmt.Errorf("%w: invalid priority", ErrMalformed)
+	}
+	if pri, err := strconv.Atoi(message[1:end]); err != nil || pri > 191 {
+		return "", fmt.Errorf("%w: invalid priority %q", ErrMalformed, message[1:end])
+	}
+	message = message[end+1:]
+
+	if strings.HasPrefix(message, "1 ") {
+		return parseRFC5424(message[2:])
+	}
+	return parseRFC3164(message)
+}
+
+// parseRFC5424 parses "TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD [MSG]".
+func parseRFC5424(message string) (string, error) {
+	for i := 0; i < 5; i++ {
+		_, rest, ok := strings.Cut(message, " ")
+		if !ok {
+			return "", fmt.Errorf("%w: truncated RFC 5424 header", ErrMalformed)
+		}
+		message = rest
+	}
+
+	// The structured data is "-" or one or more "[...]" elements, whose
+	// values may contain escaped "]".
+	switch {
+	case strings.HasPrefix(message, "-"):
+		message = message[1:]
+	case strings.HasPrefix(message, "["):
+		for strings.HasPrefix(message, "[") {
+			end := structuredDataEnd(message)
+			if end < 0 {
+				return "", fmt.Errorf("%w: unterminated structured data", ErrMalformed)
+			}
+			message = message[end+1:]
+		}
+	default:
+		return "", fmt.Errorf("%w: invalid structured data", ErrMalformed)
+	}
+
+	message = strings.TrimPrefix(message, " ")
+	return strings.TrimPrefix(message, "\ufeff"), nil
+}
+
+// structuredDataEnd returns the index of the "]" closing the structured data
+// element at the start of s, or -1.
+func structuredDataEnd(s string) int {
+	quoted := false
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			quoted = !quoted
+		case ']':
+			if !quoted {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseRFC3164 parses "Mmm dd hh:mm:ss [HOSTNAME] TAG: MSG". NGINX omits the
+// hostname with the nohostname parameter.
+func parseRFC3164(message string) (string, error) {
+	if len(message) < len(rfc3164Timestamp)+1 || message[len(rfc3164Timestamp)] != ' ' {
+		return "", fmt.Errorf("%w: missing RFC 3164 timestamp", ErrMalformed)
+	}
+	if _, err := time.Parse(rfc3164Timestamp, message[:len(rfc3164Timestamp)]); err != nil {
+		return "", fmt.Errorf("%w: invalid RFC 3164 timestamp", ErrMalformed)
+	}
+	message = message[len(rfc3164Timestamp)+1:]
+
+	// The tag ends with ":" and is preceded by the hostname, if any.
+	for i := 0; i < 2; i++ {
+		token, rest, _ := strings.Cut(message, " ")
+		if strings.HasSuffix(token, ":") {
+			return rest, nil
+		}
+		message = rest
+	}
+	return "", fmt.Errorf("%w: missing RFC 3164 tag", ErrMalformed)
+}
@@ -0,0 +1,85 @@
+package syslog
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMessage(t *testing.T) {
+	t.Parallel()
+
+	const line = `127.0.0.1 - - [10/Oct/2023:13:55:36 +0000] "GET / HTTP/1.1" 200 612 "-" "curl/8.0"`
+	tests := []struct {
+		name    string
+		message string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "RFC 3164 with hostname",
+			message: "<190>Oct 10 13:55:36 web-1 nginx: " + line,
+			want:    line,
+		},
+		{
+			name:    "RFC 3164 without hostname",
+			message: "<190>Oct  9 13:55:36 nginx: " + line + "\n",
+			want:    line,
+		},
+		{
+			name:    "RFC 5424 without structured data",
+			message: "<190>1 2023-10-10T13:55:36.123Z web-1 nginx 42 - - " + line,
+			want:    line,
+		},
+		{
+			name:    "RFC 5424 with structured data and BOM",
+			message: `<190>1 2023-10-10T13:55:36Z web-1 nginx - - [meta a="x\]y"][origin ip="10.0.0.1"] ` + "\ufeff" + line,
+			want:    line,
+		},
+		{
+			name:    "missing priority",
+			message: "Oct 10 13:55:36 web-1 nginx: " + line,
+			wantErr: true,
+		},
+		{
+			name:    "invalid priority",
+			message: "<999>Oct 10 13:55:36 web-1 nginx: " + line,
+			wantErr: true,
+		},
+		{
+			name:    "invalid RFC 3164 timestamp",
+			message: "<190>2023-10-10 13:55:36 nginx: " + line,
+			wantErr: true,
+		},
+		{
+			name:    "truncated RFC 5424 header",
+			message: "<190>1 2023-10-10T13:55:36Z web-1",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated structured data",
+			message: `<190>1 2023-10-10T13:55:36Z web-1 nginx - - [meta a="x"`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseMessage(tt.message)
+			if tt.wantErr {
+				if !errors.Is(err, ErrMalformed) {
+					t.Errorf("ParseMessage() error = %v, want %v", err, ErrMalformed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMessage() returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package syslog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+const (
+	// maxMessageSize is the largest datagram read. NGINX sends at most 2048
+	// bytes per message.
+	maxMessageSize = 64 * 1024
+	// queueSize is the number of messages waiting to be handled before new
+	// messages are dropped.
+	queueSize = 4096
+	// retryInterval is the interval to retry listening after a failure.
+	retryInterval = 5 * time.Second
+)
+
+// ParseAddress splits an address to listen on, "udp://host:port" or
+// "unix:/path", into the network and the address.
+func ParseAddress(addr string) (network, address string, err error) {
+	switch {
+	case strings.HasPrefix(addr, "udp://"):
+		address = strings.TrimPrefix(addr, "udp://")
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return "", "", fmt.Errorf("invalid syslog address %q: %w", addr, err)
+		}
+		return "udp", address, nil
+	case strings.HasPrefix(addr, "unix:"):
+		address = strings.TrimPrefix(addr, "unix:")
+		if address == "" {
+			return "", "", fmt.Errorf("invalid syslog address %q: missing path", addr)
+		}
+		return "unixgram", address, nil
+	default:
+		return "", "", fmt.Errorf("invalid syslog address %q, must be udp://host:port or unix:/path", addr)
+	}
+}
+
+// Receiver listens for syslog messages on a UDP port or a unixgram socket.
+type Receiver struct {
+	logger    log.Logger
+	network   string
+	address   string
+	received  atomic.Uint64
+	dropped   atomic.Uint64
+	malformed atomic.Uint64
+}
+
+// NewReceiver creates a Receiver which listens on addr, as accepted by
+// ParseAddress.
+func NewReceiver(addr string, logger log.Logger) (*Receiver, error) {
+	network, address, err := ParseAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Receiver{
+		logger:  logger,
+		network: network,
+		address: address,
+	}, nil
+}
+
+// Run calls handle with the content of every syslog message received until ctx
+// is done. Messages arriving while too many others wait to be handled are
+// dropped. When the receiver cannot listen, it retries periodically.
+func (r *Receiver) Run(ctx context.Context, handle func(line string)) {
+	conn, err := r.listen(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	if r.network == "unixgram" {
+		defer os.Remove(r.address)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	messages := make(chan string, queueSize)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for message := range messages {
+			line, err := ParseMessage(message)
+			if err != nil {
+				r.malformed.Add(1)
+				level.Debug(r.logger).Log("msg", "Error parsing syslog message", "message", message, "error", err.Error())
+				continue
+			}
+			handle(line)
+		}
+	}()
+	defer func() {
+		close(messages)
+		<-done
+	}()
+
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			level.Warn(r.logger).Log("msg", "Error reading syslog message", "address", r.address, "error", err.Error())
+			continue
+		}
+		r.received.Add(1)
+		select {
+		case messages <- string(buf[:n]):
+		default:
+			r.dropped.Add(1)
+		}
+	}
+}
+
+// listen listens on the address of the receiver, retrying until it succeeds
+// or ctx is done.
+func (r *Receiver) listen(ctx context.Context) (net.PacketConn, error) {
+	if r.network == "unixgram" {
+		// A socket left behind by a previous run prevents listening.
+		if fi, err := os.Lstat(r.address); err == nil && fi.Mode()&fs.ModeSocket != 0 {
+			os.Remove(r.address)
+		}
+	}
+	for {
+		conn, err := net.ListenPacket(r.network, r.address)
+		if err == nil {
+			return conn, nil
+		}
+		level.Error(r.logger).Log("msg", "Error listening for syslog messages", "address", r.address, "error", err.Error())
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// Received returns the number of messages received.
+func (r *Receiver) Received() uint64 {
+	return r.received.Load()
+}
+
+// Dropped returns the number of messages dropped because too many others
+// were waiting to be handled.
+func (r *Receiver) Dropped() uint64 {
+	return r.dropped.Load()
+}
+
+// Malformed returns the number of messages which are not syslog messages.
+func (r *Receiver) Malformed() uint64 {
+	return r.malformed.Load()
+}
@@ -0,0 +1,108 @@
+package syslog
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+func TestParseAddress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		addr        string
+		wantNetwork string
+		wantAddress string
+		wantErr     bool
+	}{
+		{addr: "udp://127.0.0.1:5514", wantNetwork: "udp", wantAddress: "127.0.0.1:5514"},
+		{addr: "udp://:5514", wantNetwork: "udp", wantAddress: ":5514"},
+		{addr: "unix:/dev/log", wantNetwork: "unixgram", wantAddress: "/dev/log"},
+		{addr: "udp://127.0.0.1", wantErr: true},
+		{addr: "unix:", wantErr: true},
+		{addr: "tcp://127.0.0.1:5514", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.addr, func(t *testing.T) {
+			t.Parallel()
+
+			network, address, err := ParseAddress(tt.addr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if network != tt.wantNetwork || address != tt.wantAddress {
+				t.Errorf("ParseAddress() = %q, %q, want %q, %q", network, address, tt.wantNetwork, tt.wantAddress)
+			}
+		})
+	}
+}
+
+func TestReceiver(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "syslog.sock")
+	r, err := NewReceiver("unix:"+path, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := make(chan string, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx, func(line string) { lines <- line })
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	var conn net.Conn
+	for i := 0; i < 100; i++ {
+		if conn, err = net.Dial("unixgram", path); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("failed to connect to the receiver: %v", err)
+	}
+	defer conn.Close()
+
+	for _, message := range []string{
+		"<190>Oct 10 13:55:36 web-1 nginx: first",
+		"not a syslog message",
+		"<190>1 2023-10-10T13:55:36Z web-1 nginx - - - second",
+	} {
+		if _, err := conn.Write([]byte(message)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, want := range []string{"first", "second"} {
+		select {
+		case got := <-lines:
+			if got != want {
+				t.Fatalf("got line %q, want %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for line %q", want)
+		}
+	}
+	if got := r.Received(); got != 3 {
+		t.Errorf("Received() = %d, want 3", got)
+	}
+	if got := r.Malformed(); got != 1 {
+		t.Errorf("Malformed() = %d, want 1", got)
+	}
+	if got := r.Dropped(); got != 0 {
+		t.Errorf("Dropped() = %d, want 0", got)
+	}
+}
@@ -0,0 +1,164 @@
+// Package tail follows files as they grow, like tail -F.
+package tail
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// Tailer follows a file and survives its rotation and truncation. A rotated
+// file is read to its end before the new file is opened; a truncated file is
+// read again from its start.
+type Tailer struct {
+	logger   log.Logger
+	path     string
+	interval time.Duration
+}
+
+// New creates a Tailer which checks path for new lines every interval.
+func New(path string, interval time.Duration, logger log.Logger) *Tailer {
+	return &Tailer{
+		logger:   logger,
+		path:     path,
+		interval: interval,
+	}
+}
+
+// Run calls handle with every line appended to the file, without the line
+// ending, until ctx is done. The lines present when Run is called are
+// skipped. A file created later, such as after a rotation, is read from its
+// start.
+func (t *Tailer) Run(ctx context.Context, handle func(line string)) {
+	f := &file{path: t.path}
+	if err := f.open(true); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		level.Warn(t.logger).Log("msg", "Error opening file", "path", t.path, "error", err.Error())
+	}
+	defer f.close()
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := f.follow(handle); err != nil {
+			level.Warn(t.logger).Log("msg", "Error following file", "path", t.path, "error", err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type file struct {
+	f       *os.File
+	reader  *bufio.Reader
+	path    string
+	partial strings.Builder
+	offset  int64
+}
+
+// open opens the file, at its end when atEnd is true.
+func (f *file) open(atEnd bool) error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	var offset int64
+	if atEnd {
+		offset, err = file.Seek(0, io.SeekEnd)
+		if err != nil {
+			file.Close()
+			return err
+		}
+	}
+	f.f = file
+	f.reader = bufio.NewReader(file)
+	f.offset = offset
+	f.partial.Reset()
+	return nil
+}
+
+func (f *file) close() {
+	if f.f != nil {
+		f.f.Close()
+		f.f = nil
+	}
+}
+
+// follow reads the lines appended since the last call, then reopens the file
+// when it was rotated or rewinds it when it was truncated.
+func (f *file) follow(handle func(line string)) error {
+	if f.f == nil {
+		if err := f.open(false); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	if err := f.read(handle); err != nil {
+		return err
+	}
+
+	current, err := os.Stat(f.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// The file was moved away and not recreated yet.
+			return nil
+		}
+		return err
+	}
+	opened, err := f.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !os.SameFile(opened, current):
+		f.close()
+		if err := f.open(false); err != nil {
+			return err
+		}
+		return f.read(handle)
+	case current.Size() < f.offset:
+		if _, err := f.f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		f.reader.Reset(f.f)
+		f.offset = 0
+		f.partial.Reset()
+		return f.read(handle)
+	}
+	return nil
+}
+
+// read calls handle with every complete line up to the end of the file. An
+// incomplete last line is kept until its line ending is written.
+func (f *file) read(handle func(line string)) error {
+	for {
+		chunk, err := f.reader.ReadString('\n')
+		f.offset += int64(len(chunk))
+		f.partial.WriteString(chunk)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		line := strings.TrimSuffix(strings.TrimSuffix(f.partial.String(), "\n"), "\r")
+		f.partial.Reset()
+		handle(line)
+	}
+}
@@ -0,0 +1,83 @@
+package tail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+func TestTailer(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "access.log")
+	write := func(flag int, s string) {
+		t.Helper()
+		f, err := os.OpenFile(path, flag|os.O_WRONLY|os.O_CREATE, 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(os.O_APPEND, "old line\n")
+
+	lines := make(chan string, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		New(path, 10*time.Millisecond, log.NewNopLogger()).Run(ctx, func(line string) { lines <- line })
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	expect := func(want ...string) {
+		t.Helper()
+		for _, w := range want {
+			select {
+			case got := <-lines:
+				if got != w {
+					t.Fatalf("got line %q, want %q", got, w)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for line %q", w)
+			}
+		}
+	}
+
+	// Let the tailer open the file at its end before appending.
+	time.Sleep(100 * time.Millisecond)
+	write(os.O_APPEND, "first\r\nsec")
+	expect("first")
+	write(os.O_APPEND, "ond\n")
+	expect("second")
+
+	// Rotation: the rest of the old file is read before the new file.
+	rotated := path + ".1"
+	write(os.O_APPEND, "before rotation\n")
+	if err := os.Rename(path, rotated); err != nil {
+		t.Fatal(err)
+	}
+	write(os.O_APPEND, "after rotation\n")
+	expect("before rotation", "after rotation")
+
+	// Truncation, as done by copytruncate.
+	write(os.O_APPEND, "a long line before truncation\n")
+	expect("a long line before truncation")
+	write(os.O_TRUNC, "truncated\n")
+	expect("truncated")
+
+	select {
+	case got := <-lines:
+		t.Errorf("got unexpected line %q", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
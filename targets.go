@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-kit/log"
+	"github.com/nginxinc/nginx-prometheus-exporter/collector"
+	"github.com/nginxinc/nginx-prometheus-exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// targetManager keeps a collector registered for every scrape target, access
+// log and error log and updates the set of collectors when the configuration is
+// reloaded.
+//
+// Every collector is registered in a registry of its own, because a registry
+// rejects metrics whose label names differ from those of metrics it
+// registered before, such as the metrics of targets with different const
+// labels. Every reload checks the collectors in new registries and rejects
+// collectors describing the same metrics as another before applying the
+// changes. Every scrape then registers the collectors in new registries, see
+// Gatherer.
+type targetManager struct {
+	logger          log.Logger
+	reloadSuccess   prometheus.Gauge
+	reloadTimestamp prometheus.Gauge
+	load            func() (*config.Config, []config.Target, error)
+	targets         map[string]*managedTarget
+	accessLogs      map[string]*managedAccessLog
+	errorLogs       map[string]*managedErrorLog
+	collectors      atomic.Pointer[[]prometheus.Collector]
+	config          atomic.Pointer[config.Config]
+	mutex           sync.Mutex
+}
+
+type managedTarget struct {
+	collector prometheus.Collector
+	poller    *collector.PollingCollector
+	transport *reloadableTransport
+	labels    map[string]string
+	target    config.Target
+}
+
+type managedAccessLog struct {
+	collector *collector.AccessLogCollector
+	labels    map[string]string
+	accessLog config.AccessLog
+}
+
+type managedErrorLog struct {
+	collector *collector.ErrorLogCollector
+	labels    map[string]string
+	errorLog  config.ErrorLog
+}
+
+// newTargetManager creates a targetManager and registers the reload metrics.
+// The load function returns the configuration and the targets to scrape on
+// every reload.
+func newTargetManager(registerer prometheus.Registerer, load func() (*config.Config, []config.Target, error), logger log.Logger) *targetManager {
+	m := &targetManager{
+		logger: logger,
+		reloadSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: exporterName,
+			Name:      "config_last_reload_successful",
+			Help:      "Whether the last configuration reload attempt was successful",
+		}),
+		reloadTimestamp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: exporterName,
+			Name:      "config_last_reload_success_timestamp_seconds",
+			Help:      "Timestamp of the last successful configuration reload",
+		}),
+		load:       load,
+		targets:    make(map[string]*managedTarget),
+		accessLogs: make(map[string]*managedAccessLog),
+		errorLogs:  make(map[string]*managedErrorLog),
+	}
+	m.collectors.Store(&[]prometheus.Collector{})
+	m.config.Store(&config.Config{})
+	registerer.MustRegister(m.reloadSuccess, m.reloadTimestamp)
+	return m
+}
+
+// Gatherer returns a prometheus.Gatherer which scrapes the targets with ctx.
+// It is meant for a single scrape.
+func (m *targetManager) Gatherer(ctx context.Context) (prometheus.Gatherer, error) {
+	collectors := *m.collectors.Load()
+	gatherers := make(prometheus.Gatherers, 0, len(collectors))
+	for _, c := range collectors {
+		registry := prometheus.NewRegistry()
+		if err := registry.Register(collector.WithContext(ctx, c)); err != nil {
+			return nil, err
+		}
+		gatherers = append(gatherers, registry)
+	}
+	return gatherers, nil
+}
+
+// Config returns the configuration loaded by the last successful reload.
+func (m *targetManager) Config() *config.Config {
+	return m.config.Load()
+}
+
+// Reload loads the configuration and replaces the collectors of added, changed
+// and removed targets and logs. Targets which did not change keep their
+// collectors but get a new transport, so certificates are re-read. Either all
+// changes are applied or, when an error is returned, none.
+func (m *targetManager) Reload() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if err := m.reload(); err != nil {
+		m.reloadSuccess.Set(0)
+		return err
+	}
+	m.reloadSuccess.Set(1)
+	m.reloadTimestamp.SetToCurrentTime()
+	return nil
+}
+
+func (m *targetManager) reload() error {
+	cfg, targets, err := m.load()
+	if err != nil {
+		return err
+	}
+
+	next := make(map[string]*managedTarget, len(targets))
+	transports := make(map[string]*http.Transport, len(targets))
+	descs := make(map[string]bool)
+	collectors := make([]prometheus.Collector, 0, len(targets))
+	for _, target := range targets {
+		labels := targetLabels(target, len(targets) > 1)
+
+		transport, endpoint, err := newTransport(target.URI, target.Module)
+		if err != nil {
+			return fmt.Errorf("target %q: %w", target.Name, err)
+		}
+		transports[target.Name] = transport
+
+		mt, ok := m.targets[target.Name]
+		if !ok || !reflect.DeepEqual(mt.target, target) || !reflect.DeepEqual(mt.labels, labels) {
+			rt := &reloadableTransport{}
+			logger := log.With(m.logger, "target", target.Name)
+			var maxConnections func() (float64, bool)
+			if target.ConfigFile != "" {
+				maxConnections = collector.ConfigMaxConnections(target.ConfigFile, nil)
+			}
+			c, err := newClientCollector(logger, target.URI, endpoint, newHTTPClient(rt, target.Module), target.Module, maxConnections, labels)
+			if err != nil {
+				return fmt.Errorf("target %q: %w", target.Name, err)
+			}
+			mt = &managedTarget{
+				collector: collector.NewScrapeCollector(c, namespaceOrDefault(target.Module), target.Mode, target.URI, labels),
+				transport: rt,
+				labels:    labels,
+				target:    target,
+			}
+			if poller := newPollingCollector(logger, mt.collector, target.Module, labels); poller != nil {
+				mt.collector = poller
+				mt.poller = poller
+			}
+		}
+		if err := checkCollector(descs, mt.collector); err != nil {
+			return fmt.Errorf("target %q: %w", target.Name, err)
+		}
+		collectors = append(collectors, mt.collector)
+		next[target.Name] = mt
+	}
+
+	nextAccessLogs := make(map[string]*managedAccessLog, len(cfg.AccessLogs))
+	for _, accessLog := range cfg.AccessLogs {
+		labels := accessLogLabels(accessLog, len(cfg.AccessLogs) > 1)
+
+		ml, ok := m.accessLogs[accessLog.Name]
+		if !ok || !reflect.DeepEqual(ml.accessLog, accessLog) || !reflect.DeepEqual(ml.labels, labels) {
+			logger := log.With(m.logger, "access_log", accessLog.Name)
+			c, err := newAccessLogCollector(logger, accessLog, labels)
+			if err != nil {
+				return fmt.Errorf("access log %q: %w", accessLog.Name, err)
+			}
+			ml = &managedAccessLog{
+				collector: c,
+				labels:    labels,
+				accessLog: accessLog,
+			}
+		}
+		if err := checkCollector(descs, ml.collector); err != nil {
+			return fmt.Errorf("access log %q: %w", accessLog.Name, err)
+		}
+		collectors = append(collectors, ml.collector)
+		nextAccessLogs[accessLog.Name] = ml
+	}
+
+	nextErrorLogs := make(map[string]*managedErrorLog, len(cfg.ErrorLogs))
+	for _, errorLog := range cfg.ErrorLogs {
+		labels := errorLogLabels(errorLog, len(cfg.ErrorLogs) > 1)
+
+		ml, ok := m.errorLogs[errorLog.Name]
+		if !ok || !reflect.DeepEqual(ml.errorLog, errorLog) || !reflect.DeepEqual(ml.labels, labels) {
+			logger := log.With(m.logger, "error_log", errorLog.Name)
+			c, err := newErrorLogCollector(logger, errorLog, labels)
+			if err != nil {
+				return fmt.Errorf("error log %q: %w", errorLog.Name, err)
+			}
+			ml = &managedErrorLog{
+				collector: c,
+				labels:    labels,
+				errorLog:  errorLog,
+			}
+		}
+		if err := checkCollector(descs, ml.collector); err != nil {
+			return fmt.Errorf("error log %q: %w", errorLog.Name, err)
+		}
+		collectors = append(collectors, ml.collector)
+		nextErrorLogs[errorLog.Name] = ml
+	}
+
+	for name, mt := range next {
+		mt.transport.swap(transports[name])
+		if mt.poller != nil {
+			mt.poller.Start()
+		}
+	}
+	m.collectors.Store(&collectors)
+	for name, mt := range m.targets {
+		if next[name] != mt {
+			if mt.poller != nil {
+				mt.poller.Stop()
+			}
+			mt.transport.closeIdleConnections()
+		}
+	}
+	// Stop the replaced logs first, so their syslog addresses are free again.
+	for name, ml := range m.accessLogs {
+		if nextAccessLogs[name] != ml {
+			ml.collector.Stop()
+		}
+	}
+	for name, ml := range m.errorLogs {
+		if nextErrorLogs[name] != ml {
+			ml.collector.Stop()
+		}
+	}
+	for _, ml := range nextAccessLogs {
+		ml.collector.Start()
+	}
+	for _, ml := range nextErrorLogs {
+		ml.collector.Start()
+	}
+
+	m.targets = next
+	m.accessLogs = nextAccessLogs
+	m.errorLogs = nextErrorLogs
+	m.config.Store(cfg)
+
+	return nil
+}
+
+// checkCollector registers c in a new registry, which validates its
+// descriptors, and fails when a descriptor of c is in descs, the descriptors
+// of the collectors checked before, as both would collect the same metrics.
+// The descriptors of c are added to descs.
+func checkCollector(descs map[string]bool, c prometheus.Collector) error {
+	if err := prometheus.NewRegistry().Register(c); err != nil {
+		return err
+	}
+	ch := make(chan *prometheus.Desc)
+	go func() {
+		c.Describe(ch)
+		close(ch)
+	}()
+	var err error
+	for desc := range ch {
+		// The string of a descriptor holds its name and its labels.
+		key := desc.String()
+		if descs[key] && err == nil {
+			err = fmt.Errorf("duplicate metrics %s", desc)
+		}
+		descs[key] = true
+	}
+	return err
+}
+
+// reloadableTransport is an http.RoundTripper whose transport is replaced on
+// reload without recreating the client using it.
+type reloadableTransport struct {
+	transport atomic.Pointer[http.Transport]
+}
+
+func (rt *reloadableTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return rt.transport.Load().RoundTrip(req)
+}
+
+// swap replaces the transport and closes the idle connections of the old one.
+func (rt *reloadableTransport) swap(transport *http.Transport) {
+	if old := rt.transport.Swap(transport); old != nil {
+		old.CloseIdleConnections()
+	}
+}
+
+func (rt *reloadableTransport) closeIdleConnections() {
+	if transport := rt.transport.Load(); transport != nil {
+		transport.CloseIdleConnections()
+	}
+}
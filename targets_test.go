@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/nginxinc/nginx-prometheus-exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestTargetManagerReload(t *testing.T) {
+	t.Parallel()
+
+	edge := config.Target{Name: "edge", URI: "http://127.0.0.1:1/stub_status", Module: config.Module{Mode: config.ModeOSS}}
+	api := config.Target{Name: "api", URI: "http://127.0.0.1:2/api", Module: config.Module{Mode: config.ModePlus}}
+	polled := config.Target{Name: "polled", URI: "http://127.0.0.1:3/stub_status", Module: config.Module{Mode: config.ModeOSS, PollInterval: time.Hour}}
+	broken := config.Target{Name: "broken", URI: "unix:/too:/many:colons:", Module: config.Module{Mode: config.ModeOSS}}
+
+	var targets []config.Target
+	var loadErr error
+	load := func() (*config.Config, []config.Target, error) {
+		return &config.Config{Targets: targets}, targets, loadErr
+	}
+
+	registry := prometheus.NewRegistry()
+	m := newTargetManager(registry, load, log.NewNopLogger())
+
+	targets = []config.Target{edge}
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload() returned error: %v", err)
+	}
+	edgeCollector := m.targets["edge"].collector
+
+	targets = []config.Target{edge, api}
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload() returned error: %v", err)
+	}
+	if len(m.targets) != 2 {
+		t.Fatalf("Reload() kept %d targets, want 2", len(m.targets))
+	}
+	if m.targets["edge"].collector == edgeCollector {
+		t.Errorf("Reload() kept the collector of target edge although its labels changed")
+	}
+	edgeCollector = m.targets["edge"].collector
+
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload() returned error: %v", err)
+	}
+	if m.targets["edge"].collector != edgeCollector {
+		t.Errorf("Reload() replaced the collector of unchanged target edge")
+	}
+
+	targets = []config.Target{edge, api, broken}
+	if err := m.Reload(); err == nil {
+		t.Fatalf("Reload() returned no error for an invalid target")
+	}
+	if len(m.targets) != 2 || m.targets["edge"].collector != edgeCollector {
+		t.Errorf("Reload() changed the targets although it failed")
+	}
+	if got := gaugeValue(t, m.reloadSuccess); got != 0 {
+		t.Errorf("config_last_reload_successful = %v, want 0", got)
+	}
+
+	targets = nil
+	loadErr = errors.New("no scrape addresses provided")
+	if err := m.Reload(); err == nil {
+		t.Fatalf("Reload() returned no error for a failed load")
+	}
+
+	targets = []config.Target{api, polled}
+	loadErr = nil
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload() returned error: %v", err)
+	}
+	if _, ok := m.targets["edge"]; ok {
+		t.Errorf("Reload() kept removed target edge")
+	}
+	if m.targets["polled"].poller == nil {
+		t.Errorf("Reload() did not create a polling collector for target polled")
+	}
+	if got := gaugeValue(t, m.reloadSuccess); got != 1 {
+		t.Errorf("config_last_reload_successful = %v, want 1", got)
+	}
+	if slices.Contains(*m.collectors.Load(), edgeCollector) {
+		t.Errorf("Reload() kept the collector of removed target edge")
+	}
+}
+
+// gather scrapes the collectors of m like a scrape of the metrics endpoint.
+func gather(t *testing.T, m *targetManager) ([]*dto.MetricFamily, error) {
+	t.Helper()
+
+	g, err := m.Gatherer(context.Background())
+	if err != nil {
+		t.Fatalf("Gatherer() returned error: %v", err)
+	}
+	return g.Gather()
+}
+
+func gaugeValue(t *testing.T, g prometheus.Gauge) float64 {
+	t.Helper()
+
+	var m dto.Metric
+	if err := g.Write(&m); err != nil {
+		t.Fatalf("failed to write gauge: %v", err)
+	}
+	return m.GetGauge().GetValue()
+}
+
+func TestTargetManagerConstLabels(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		targets []config.Target
+		wantErr bool
+	}{
+		{
+			name: "different const label names",
+			targets: []config.Target{
+				{Name: "prod", URI: "http://127.0.0.1:1/stub_status", ConstLabels: map[string]string{"env": "prod"}, Module: config.Module{Mode: config.ModeOSS}},
+				{Name: "plain", URI: "http://127.0.0.1:2/stub_status", Module: config.Module{Mode: config.ModeOSS}},
+			},
+		},
+		{
+			name: "same metrics",
+			targets: []config.Target{
+				{Name: "a", URI: "http://127.0.0.1:1/stub_status", Module: config.Module{Mode: config.ModeOSS}},
+				{Name: "b", URI: "http://127.0.0.1:1/stub_status", Module: config.Module{Mode: config.ModeOSS}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			load := func() (*config.Config, []config.Target, error) {
+				return &config.Config{Targets: tt.targets}, tt.targets, nil
+			}
+			m := newTargetManager(prometheus.NewRegistry(), load, log.NewNopLogger())
+			err := m.Reload()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Reload() returned no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Reload() returned error: %v", err)
+			}
+
+			families, err := gather(t, m)
+			if err != nil {
+				t.Fatalf("Gather() returned error: %v", err)
+			}
+			var ups int
+			for _, f := range families {
+				if f.GetName() == "nginx_up" {
+					ups = len(f.GetMetric())
+				}
+			}
+			if ups != len(tt.targets) {
+				t.Errorf("nginx_up has %d series, want %d", ups, len(tt.targets))
+			}
+		})
+	}
+}
+
+func TestTargetManagerReloadAccessLogs(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "access.log")
+	web := config.AccessLog{Name: "web", Paths: []string{path}, Format: "combined"}
+	api := config.AccessLog{Name: "api", Paths: []string{path + ".api"}, Format: "json"}
+	target := config.Target{Name: "edge", URI: "http://127.0.0.1:1/stub_status", Module: config.Module{Mode: config.ModeOSS}}
+
+	var accessLogs []config.AccessLog
+	load := func() (*config.Config, []config.Target, error) {
+		return &config.Config{AccessLogs: accessLogs}, []config.Target{target}, nil
+	}
+
+	m := newTargetManager(prometheus.NewRegistry(), load, log.NewNopLogger())
+	defer func() {
+		for _, ml := range m.accessLogs {
+			ml.collector.Stop()
+		}
+	}()
+
+	accessLogs = []config.AccessLog{web}
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload() returned error: %v", err)
+	}
+	webCollector := m.accessLogs["web"].collector
+
+	// Let the tailer start before the file is created, so it is read from its start.
+	time.Sleep(200 * time.Millisecond)
+	line := `192.0.2.1 - - [17/Oct/2026:10:00:00 +0000] "GET / HTTP/1.1" 200 612 "-" "curl/8.0.1"` + "\n"
+	if err := os.WriteFile(path, []byte(line), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		families, err := gather(t, m)
+		if err != nil {
+			t.Fatalf("Gather() returned error: %v", err)
+		}
+		if counterValue(families, "nginx_access_log_requests_total") == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the access log line was not counted")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload() returned error: %v", err)
+	}
+	if m.accessLogs["web"].collector != webCollector {
+		t.Errorf("Reload() replaced the collector of unchanged access log web")
+	}
+
+	accessLogs = []config.AccessLog{web, api}
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload() returned error: %v", err)
+	}
+	if m.accessLogs["web"].collector == webCollector {
+		t.Errorf("Reload() kept the collector of access log web although its labels changed")
+	}
+	if got := m.accessLogs["api"].labels["access_log"]; got != "api" {
+		t.Errorf("access_log label of access log api = %q, want %q", got, "api")
+	}
+}
+
+func TestTargetManagerConfigFile(t *testing.T) {
+	t.Parallel()
+
+	nginx := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "Active connections: 25 \nserver accepts handled requests\n 100 100 300 \nReading: 0 Writing: 1 Waiting: 24 \n")
+	}))
+	t.Cleanup(nginx.Close)
+
+	targets := []config.Target{
+		{Name: "edge", URI: nginx.URL + "/stub_status", ConfigFile: "collector/testdata/nginx.conf", Module: config.Module{Mode: config.ModeOSS}},
+		{Name: "other", URI: nginx.URL + "/other_status", Module: config.Module{Mode: config.ModeOSS}},
+	}
+	load := func() (*config.Config, []config.Target, error) {
+		return &config.Config{Targets: targets}, targets, nil
+	}
+
+	m := newTargetManager(prometheus.NewRegistry(), load, log.NewNopLogger())
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload() returned error: %v", err)
+	}
+	families, err := gather(t, m)
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+
+	want := map[string]float64{nginx.URL + "/stub_status": 0.25}
+	if got := gaugesByAddr(families, "nginx_connections_utilization_ratio"); !reflect.DeepEqual(got, want) {
+		t.Errorf("nginx_connections_utilization_ratio by addr = %v, want %v", got, want)
+	}
+}
+
+func TestTargetManagerMixedTargets(t *testing.T) {
+	t.Parallel()
+
+	newStubStatusServer := func(active int) *httptest.Server {
+		return httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprintf(w, "Active connections: %d \nserver accepts handled requests\n 1 1 1 \nReading: 0 Writing: 1 Waiting: 0 \n", active)
+		}))
+	}
+
+	dir := t.TempDir()
+	var sockets []config.Target
+	for i, active := range []int{10, 20} {
+		socketPath := filepath.Join(dir, fmt.Sprintf("nginx-%d.sock", i))
+		listener, err := net.Listen("unix", socketPath)
+		if err != nil {
+			t.Fatalf("failed to listen on unix socket: %v", err)
+		}
+		srv := newStubStatusServer(active)
+		srv.Listener = listener
+		srv.Start()
+		t.Cleanup(srv.Close)
+		sockets = append(sockets, config.Target{Name: fmt.Sprintf("socket-%d", i), URI: "unix:" + socketPath + ":/stub_status", Module: config.Module{Mode: config.ModeOSS}})
+	}
+	tcpServer := newStubStatusServer(30)
+	tcpServer.Start()
+	t.Cleanup(tcpServer.Close)
+	tcp := config.Target{Name: "tcp", URI: tcpServer.URL + "/stub_status", Module: config.Module{Mode: config.ModeOSS}}
+
+	var targets []config.Target
+	load := func() (*config.Config, []config.Target, error) {
+		return &config.Config{Targets: targets}, targets, nil
+	}
+	m := newTargetManager(prometheus.NewRegistry(), load, log.NewNopLogger())
+
+	// The second reload keeps the collectors of the first targets and
+	// gives them new transports, which must still dial their own address.
+	steps := []struct {
+		name    string
+		targets []config.Target
+		want    map[string]float64
+	}{
+		{
+			name:    "one socket",
+			targets: []config.Target{sockets[0], tcp},
+			want:    map[string]float64{sockets[0].URI: 10, tcp.URI: 30},
+		},
+		{
+			name:    "another socket",
+			targets: []config.Target{sockets[0], tcp, sockets[1]},
+			want:    map[string]float64{sockets[0].URI: 10, tcp.URI: 30, sockets[1].URI: 20},
+		},
+	}
+	for _, step := range steps {
+		targets = step.targets
+		if err := m.Reload(); err != nil {
+			t.Fatalf("%s: Reload() returned error: %v", step.name, err)
+		}
+		families, err := gather(t, m)
+		if err != nil {
+			t.Fatalf("%s: Gather() returned error: %v", step.name, err)
+		}
+		if got := gaugesByAddr(families, "nginx_connections_active"); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: nginx_connections_active by addr = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+// gaugesByAddr returns the values of the gauge by the addr label.
+func gaugesByAddr(families []*dto.MetricFamily, name string) map[string]float64 {
+	values := make(map[string]float64)
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+		for _, metric := range f.GetMetric() {
+			for _, l := range metric.GetLabel() {
+				if l.GetName() == "addr" {
+					values[l.GetValue()] = metric.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	return values
+}
+
+func counterValue(families []*dto.MetricFamily, name string) float64 {
+	var sum float64
+	for _, f := range families {
+		if f.GetName() == name {
+			for _, m := range f.GetMetric() {
+				sum += m.GetCounter().GetValue()
+			}
+		}
+	}
+	return sum
+}